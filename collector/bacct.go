@@ -0,0 +1,240 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	kingpin "github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bacctWindows  = kingpin.Flag("collector.bacct.window", "Time window of the jobs summarized by bacct -C, ending now, e.g. 1h or 24h. Can be repeated.").Default("1h", "24h").Strings()
+	bacctQueues   = kingpin.Flag("collector.bacct.queue", "Also summarize the jobs of the queue with bacct -q. Can be repeated.").Strings()
+	bacctProjects = kingpin.Flag("collector.bacct.project", "Also summarize the jobs of the project with bacct -P. Can be repeated.").Strings()
+	bacctInterval = kingpin.Flag("collector.bacct.interval", "Interval of the bacct runs in the background, the scrapes export the latest summaries.").Default("5m").Duration()
+)
+
+// bacctStatistics maps the labels of the SUMMARY of bacct to the metric and
+// the statistic label.
+var bacctStatistics = map[string][2]string{
+	"Total CPU time consumed":     {"cpu_time_seconds", "total"},
+	"Average CPU time consumed":   {"cpu_time_seconds", "average"},
+	"Maximum CPU time of a job":   {"cpu_time_seconds", "maximum"},
+	"Minimum CPU time of a job":   {"cpu_time_seconds", "minimum"},
+	"Total wait time in queues":   {"wait_time_seconds", "total"},
+	"Average wait time in queue":  {"wait_time_seconds", "average"},
+	"Maximum wait time in queue":  {"wait_time_seconds", "maximum"},
+	"Minimum wait time in queue":  {"wait_time_seconds", "minimum"},
+	"Average turnaround time":     {"turnaround_time_seconds", "average"},
+	"Maximum turnaround time":     {"turnaround_time_seconds", "maximum"},
+	"Minimum turnaround time":     {"turnaround_time_seconds", "minimum"},
+	"Average hog factor of a job": {"hog_factor", "average"},
+	"Maximum hog factor of a job": {"hog_factor", "maximum"},
+	"Minimum hog factor of a job": {"hog_factor", "minimum"},
+}
+
+// bacctKey are the dimension, name and window labels of a summary.
+type bacctKey [3]string
+
+type bacctCollector struct {
+	DoneJobs       *prometheus.Desc
+	ExitedJobs     *prometheus.Desc
+	Throughput     *prometheus.Desc
+	CpuTime        *prometheus.Desc
+	WaitTime       *prometheus.Desc
+	TurnaroundTime *prometheus.Desc
+	HogFactor      *prometheus.Desc
+	Timestamp      *prometheus.Desc
+	windows        map[string]time.Duration
+	// summaries are the results of the latest bacct runs.
+	summaries map[bacctKey]map[string]float64
+	refreshed time.Time
+	mtx       sync.Mutex
+	logger    log.Logger
+}
+
+func init() {
+	registerCollector("bacct", false, NewLSFBacctCollector)
+}
+
+// NewLSFBacctCollector returns a new Collector exposing the job accounting
+// summary of bacct.
+func NewLSFBacctCollector(logger log.Logger) (Collector, error) {
+	windows := map[string]time.Duration{}
+	for _, w := range *bacctWindows {
+		d, err := time.ParseDuration(w)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid bacct window %q", w)
+		}
+		windows[w] = d
+	}
+
+	labels := []string{"dimension", "name", "window"}
+	statLabels := append(labels, "stat")
+	c := &bacctCollector{
+		DoneJobs: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bacct", "done_jobs"),
+			"The number of jobs done in the window.",
+			labels, nil,
+		),
+		ExitedJobs: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bacct", "exited_jobs"),
+			"The number of jobs exited in the window.",
+			labels, nil,
+		),
+		Throughput: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bacct", "throughput_jobs_per_hour"),
+			"The number of jobs finished per hour in the window.",
+			labels, nil,
+		),
+		CpuTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bacct", "cpu_time_seconds"),
+			"The total, average, maximum and minimum CPU time of the jobs finished in the window.",
+			statLabels, nil,
+		),
+		WaitTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bacct", "wait_time_seconds"),
+			"The total, average, maximum and minimum time the jobs finished in the window waited in the queue.",
+			statLabels, nil,
+		),
+		TurnaroundTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bacct", "turnaround_time_seconds"),
+			"The average, maximum and minimum turnaround time of the jobs finished in the window.",
+			statLabels, nil,
+		),
+		HogFactor: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bacct", "hog_factor"),
+			"The average, maximum and minimum hog factor (CPU time / turnaround time) of the jobs finished in the window.",
+			statLabels, nil,
+		),
+		Timestamp: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bacct", "timestamp_seconds"),
+			"The time of the latest bacct runs of which at least one succeeded since the epoch, the summaries are refreshed every --collector.bacct.interval and dropped when their bacct fails.",
+			nil, nil,
+		),
+		windows:   windows,
+		summaries: map[bacctKey]map[string]float64{},
+		logger:    logger,
+	}
+	// Like the probe collector, the collector is created once and its
+	// goroutine runs until the exporter exits, there is no way to stop it.
+	go c.run()
+
+	return c, nil
+}
+
+// Update calls (*bacctCollector).parseBacct to get the accounting summary
+// metrics.
+func (c *bacctCollector) Update(ch chan<- prometheus.Metric) error {
+	err := c.parseBacct(ch)
+	if err != nil {
+		return fmt.Errorf("couldn't get bacct infomation: %w", err)
+	}
+
+	return nil
+}
+
+// bacct_ParseOutput returns the values of the SUMMARY of bacct by their
+// label. Without finished jobs bacct prints no SUMMARY and the map is empty.
+func bacct_ParseOutput(lsfOutput []byte) map[string]float64 {
+	summary := map[string]float64{}
+	for _, match := range BacctSummaryRegex.FindAllStringSubmatch(string(lsfOutput), -1) {
+		if v, err := strconv.ParseFloat(match[2], 64); err == nil {
+			summary[match[1]] = v
+		}
+	}
+	return summary
+}
+
+// run refreshes the summaries every --collector.bacct.interval. bacct reads
+// the whole accounting history of the windows, which is too slow for a scrape.
+func (c *bacctCollector) run() {
+	for {
+		c.refresh()
+		time.Sleep(*bacctInterval)
+	}
+}
+
+// refresh runs bacct for every window and dimension. The summary of a failed
+// bacct is dropped instead of exporting a stale one.
+func (c *bacctCollector) refresh() {
+	type dimension struct {
+		name, value, flag string
+	}
+	dimensions := []dimension{{"cluster", "all", ""}}
+	for _, q := range *bacctQueues {
+		dimensions = append(dimensions, dimension{"queue", q, "-q"})
+	}
+	for _, p := range *bacctProjects {
+		dimensions = append(dimensions, dimension{"project", p, "-P"})
+	}
+
+	summaries := map[bacctKey]map[string]float64{}
+	now := time.Now()
+	for window, d := range c.windows {
+		// bacct -C takes the begin time as year/month/day/hour:minute, the end
+		// time is left out to end now.
+		interval := now.Add(-d).Format("2006/01/02/15:04") + ","
+		for _, dim := range dimensions {
+			args := []string{"-u", "all", "-C", interval}
+			if dim.flag != "" {
+				args = append(args, dim.flag, dim.value)
+			}
+			output, err := lsfOutput(c.logger, "bacct", args...)
+			if err != nil {
+				level.Error(c.logger).Log("err: ", err)
+				continue
+			}
+			summaries[bacctKey{dim.name, dim.value, window}] = bacct_ParseOutput(output)
+		}
+	}
+
+	c.mtx.Lock()
+	c.summaries = summaries
+	if len(summaries) > 0 {
+		c.refreshed = now
+	}
+	c.mtx.Unlock()
+}
+
+func (c *bacctCollector) parseBacct(ch chan<- prometheus.Metric) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.refreshed.IsZero() {
+		return nil
+	}
+	ch <- prometheus.MustNewConstMetric(c.Timestamp, prometheus.GaugeValue, float64(c.refreshed.Unix()))
+
+	for key, summary := range c.summaries {
+		labels := key[:]
+		ch <- prometheus.MustNewConstMetric(c.DoneJobs, prometheus.GaugeValue, summary["Total number of done jobs"], labels...)
+		ch <- prometheus.MustNewConstMetric(c.ExitedJobs, prometheus.GaugeValue, summary["Total number of exited jobs"], labels...)
+		ch <- prometheus.MustNewConstMetric(c.Throughput, prometheus.GaugeValue, summary["Total throughput"], labels...)
+		for label, v := range summary {
+			stat, ok := bacctStatistics[label]
+			if !ok {
+				continue
+			}
+			var desc *prometheus.Desc
+			switch stat[0] {
+			case "cpu_time_seconds":
+				desc = c.CpuTime
+			case "wait_time_seconds":
+				desc = c.WaitTime
+			case "turnaround_time_seconds":
+				desc = c.TurnaroundTime
+			case "hog_factor":
+				desc = c.HogFactor
+			}
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, append(labels, stat[1])...)
+		}
+	}
+
+	return nil
+}
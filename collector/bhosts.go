@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strings"
 
+	kingpin "github.com/alecthomas/kingpin/v2"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/jszwec/csvutil"
@@ -22,9 +23,17 @@ type bHostsCollector struct {
 	HostSSUSPJobCount *prometheus.Desc
 	HostUSUSPJobCount *prometheus.Desc
 	HostStatus        *prometheus.Desc
-	logger            log.Logger
+
+	HostStatusInfo     *prometheus.Desc
+	HostLoadThreshold  *prometheus.Desc
+	HostDispatchWindow *prometheus.Desc
+	logger             log.Logger
 }
 
+var (
+	bhostsLong = kingpin.Flag("collector.bhosts.long", "Also parse `bhosts -l` for the closed reasons, admin comments, load thresholds and dispatch windows of the hosts.").Default("false").Bool()
+)
+
 func init() {
 	registerCollector("bhosts", defaultEnabled, NewLSFbHostCollector)
 }
@@ -63,6 +72,21 @@ func NewLSFbHostCollector(logger log.Logger) (Collector, error) {
 			"The status of the host and the sbatchd daemon. Batch jobs can be dispatched only to hosts with an ok status. Host status has the following, 0:Unknow, 1:ok, 2:unavail, 3:unreach, 4:closed/closed_full, 5:closed_cu_excl",
 			[]string{"host_name"}, nil,
 		),
+		HostStatusInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bhost", "status_info"),
+			"A metric with a constant '1' value labeled by the detailed status, the closed reason and the admin action comment of the host from bhosts -l. The closed reason is the closed status token of LSF, e.g. closed_Adm closed by the administrator, closed_Busy a load threshold is exceeded, closed_Excl an exclusive job runs, closed_Full all job slots are used, closed_LIM LIM is unavailable, closed_Lock locked with lsadmin limlock, closed_Wind a dispatch window is closed.",
+			[]string{"host_name", "status", "closed_reason", "admin_comment"}, nil,
+		),
+		HostLoadThreshold: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bhost", "load_threshold"),
+			"The configured loadSched and loadStop thresholds of the host from bhosts -l. Values with a unit are converted to KB. Thresholds that are not configured are not exported.",
+			[]string{"host_name", "index", "threshold"}, nil,
+		),
+		HostDispatchWindow: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bhost", "dispatch_window_info"),
+			"A metric with a constant '1' value labeled by the configured dispatch windows of the host from bhosts -l.",
+			[]string{"host_name", "window"}, nil,
+		),
 		logger: logger,
 	}, nil
 }
@@ -81,6 +105,13 @@ func (c *bHostsCollector) Update(ch chan<- prometheus.Metric) error {
 		return fmt.Errorf("couldn't get bhosts infomation: %w", err)
 	}
 
+	if *bhostsLong {
+		err = c.parsebHostLong(ch)
+		if err != nil {
+			return fmt.Errorf("couldn't get bhosts -l infomation: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -171,3 +202,94 @@ func (c *bHostsCollector) parsebHostJobCount(ch chan<- prometheus.Metric) error
 
 	return nil
 }
+
+// bhostLong_ParseOutput parses the output of `bhosts -l`.
+func bhostLong_ParseOutput(lsfOutput []byte) []bhostLongInfo {
+	var bhosts []bhostLongInfo
+	var header []string
+	cur := -1
+	inThreshold := false
+
+	lines := strings.Split(string(lsfOutput), "\n")
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case fields[0] == "HOST" && len(fields) == 2:
+			bhosts = append(bhosts, bhostLongInfo{
+				HOST_NAME: fields[1],
+				LoadSched: map[string]string{},
+				LoadStop:  map[string]string{},
+			})
+			cur = len(bhosts) - 1
+			inThreshold = false
+		case cur < 0:
+			continue
+		case fields[0] == "STATUS" && i+1 < len(lines):
+			// STATUS CPUF JL/U MAX NJOBS RUN SSUSP USUSP RSV DISPATCH_WINDOW
+			values := strings.Fields(lines[i+1])
+			if len(values) > 0 {
+				bhosts[cur].STATUS = values[0]
+			}
+			if len(values) > 9 {
+				bhosts[cur].DISPATCH_WINDOW = strings.Join(values[9:], " ")
+			}
+		case strings.HasPrefix(trimmed, "ADMIN ACTION COMMENT:"):
+			comment := strings.TrimSpace(strings.TrimPrefix(trimmed, "ADMIN ACTION COMMENT:"))
+			bhosts[cur].ADMIN_COMMENT = strings.Trim(comment, `"`)
+		case strings.HasPrefix(trimmed, "LOAD THRESHOLD"):
+			inThreshold = true
+			header = nil
+		case strings.HasSuffix(trimmed, ":"):
+			inThreshold = false
+		case inThreshold && (fields[0] == "loadSched" || fields[0] == "loadStop"):
+			thresholds := bhosts[cur].LoadSched
+			if fields[0] == "loadStop" {
+				thresholds = bhosts[cur].LoadStop
+			}
+			for j, v := range fields[1:] {
+				if j < len(header) {
+					thresholds[header[j]] = v
+				}
+			}
+		case inThreshold:
+			header = fields
+		}
+	}
+	return bhosts
+}
+
+func (c *bHostsCollector) parsebHostLong(ch chan<- prometheus.Metric) error {
+	output, err := lsfOutput(c.logger, "bhosts", "-l")
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+
+	for _, bhost := range bhostLong_ParseOutput(output) {
+		reason := ""
+		if strings.HasPrefix(strings.ToLower(bhost.STATUS), "closed_") {
+			reason = bhost.STATUS
+		}
+		ch <- prometheus.MustNewConstMetric(c.HostStatusInfo, prometheus.GaugeValue, 1, bhost.HOST_NAME, bhost.STATUS, reason, bhost.ADMIN_COMMENT)
+
+		if bhost.DISPATCH_WINDOW != "" && bhost.DISPATCH_WINDOW != "-" {
+			ch <- prometheus.MustNewConstMetric(c.HostDispatchWindow, prometheus.GaugeValue, 1, bhost.HOST_NAME, bhost.DISPATCH_WINDOW)
+		}
+
+		for index, v := range bhost.LoadSched {
+			if value, ok := ConvertLoadValue(v); ok {
+				ch <- prometheus.MustNewConstMetric(c.HostLoadThreshold, prometheus.GaugeValue, value, bhost.HOST_NAME, index, "loadSched")
+			}
+		}
+		for index, v := range bhost.LoadStop {
+			if value, ok := ConvertLoadValue(v); ok {
+				ch <- prometheus.MustNewConstMetric(c.HostLoadThreshold, prometheus.GaugeValue, value, bhost.HOST_NAME, index, "loadStop")
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type bhpartCollector struct {
+	PartitionHosts *prometheus.Desc
+	Fairshare      *fairshareMetrics
+	logger         log.Logger
+}
+
+func init() {
+	registerCollector("bhpart", false, NewLSFbhpartCollector)
+}
+
+// NewLSFbhpartCollector returns a new Collector exposing the host partition
+// fairshare information of `bhpart -r`.
+func NewLSFbhpartCollector(logger log.Logger) (Collector, error) {
+
+	return &bhpartCollector{
+		PartitionHosts: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bhpart", "hosts_info"),
+			"A metric with a constant '1' value labeled by the HOSTS list of the host partition.",
+			[]string{"host_partition", "hosts"}, nil,
+		),
+		Fairshare: newFairshareMetrics(),
+		logger:    logger,
+	}, nil
+}
+
+// Update calls (*bhpartCollector).parseHostPartitions to get the host
+// partition fairshare metrics.
+func (c *bhpartCollector) Update(ch chan<- prometheus.Metric) error {
+	err := c.parseHostPartitions(ch)
+	if err != nil {
+		return fmt.Errorf("couldn't get bhpart infomation: %w", err)
+	}
+
+	return nil
+}
+
+func (c *bhpartCollector) parseHostPartitions(ch chan<- prometheus.Metric) error {
+	output, err := lsfOutput(c.logger, "bhpart", "-r")
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+
+	var partition string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "HOST_PARTITION_NAME:"):
+			partition = strings.TrimSpace(strings.TrimPrefix(line, "HOST_PARTITION_NAME:"))
+		case strings.HasPrefix(line, "HOSTS:") && partition != "":
+			hosts := strings.Join(strings.Fields(strings.TrimPrefix(line, "HOSTS:")), " ")
+			ch <- prometheus.MustNewConstMetric(c.PartitionHosts, prometheus.GaugeValue, 1, partition, hosts)
+		}
+	}
+
+	c.Fairshare.collect(ch, "host_partition", fairshare_ParseOutput(output))
+
+	return nil
+}
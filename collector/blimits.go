@@ -0,0 +1,153 @@
+package collector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type blimitsCollector struct {
+	LimitUsage *prometheus.Desc
+	LimitMax   *prometheus.Desc
+	logger     log.Logger
+}
+
+func init() {
+	registerCollector("blimits", false, NewLSFblimitsCollector)
+}
+
+// blimitsConsumers are the consumer columns of `blimits -w`, every other
+// column is a limited resource. The labels are the lower case column names.
+var blimitsConsumers = []string{"NAME", "USERS", "QUEUES", "HOSTS", "PROJECTS", "LIC_PROJECTS", "APPS"}
+
+// blimitsKey are the consumer labels and the resource label of a limit.
+type blimitsKey [8]string
+
+// blimitsValue is the usage and the maximum of a resource of a limit.
+type blimitsValue struct {
+	usage, limit       float64
+	hasUsage, hasLimit bool
+}
+
+// NewLSFblimitsCollector returns a new Collector exposing the resource limit
+// consumption of `blimits -w`.
+func NewLSFblimitsCollector(logger log.Logger) (Collector, error) {
+	var labels []string
+	for _, column := range blimitsConsumers {
+		labels = append(labels, strings.ToLower(column))
+	}
+	labels = append(labels, "resource")
+
+	return &blimitsCollector{
+		LimitUsage: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "blimits", "usage"),
+			"The current usage of the resource by the consumers of the limit. Values with a unit are converted to KB, the usage of rows with the same labels is summed.",
+			labels, nil,
+		),
+		LimitMax: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "blimits", "limit"),
+			"The configured maximum of the resource for the consumers of the limit. Values with a unit are converted to KB, rows with the same labels export the largest maximum.",
+			labels, nil,
+		),
+		logger: logger,
+	}, nil
+}
+
+// Update calls (*blimitsCollector).parseLimits to get the limit metrics.
+func (c *blimitsCollector) Update(ch chan<- prometheus.Metric) error {
+	err := c.parseLimits(ch)
+	if err != nil {
+		return fmt.Errorf("couldn't get blimits infomation: %w", err)
+	}
+
+	return nil
+}
+
+// blimits_ParseOutput parses the INTERNAL and EXTERNAL RESOURCE LIMITS tables
+// of `blimits -w` into one map per row, keyed by the column names.
+func blimits_ParseOutput(lsfOutput []byte) []map[string]string {
+	var rows []map[string]string
+	var header []string
+
+	for _, line := range strings.Split(string(lsfOutput), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case strings.HasSuffix(strings.TrimSpace(line), "LIMITS:"):
+			header = nil
+		case fields[0] == "NAME":
+			header = fields
+		case header == nil || len(fields) != len(header):
+			continue
+		default:
+			row := make(map[string]string, len(header))
+			for i, column := range header {
+				row[column] = fields[i]
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func (c *blimitsCollector) parseLimits(ch chan<- prometheus.Metric) error {
+	output, err := lsfOutput(c.logger, "blimits", "-w")
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+
+	// Rows that only differ in a column without a label, e.g. a consumer
+	// column of a newer LSF version, share the series: their usage is summed
+	// and the maximum of the limit, which the rows repeat, is taken once.
+	values := map[blimitsKey]*blimitsValue{}
+	for _, row := range blimits_ParseOutput(output) {
+		var key blimitsKey
+		for i, column := range blimitsConsumers {
+			key[i] = row[column]
+			if key[i] == "" {
+				key[i] = "-"
+			}
+			delete(row, column)
+		}
+
+		for resource, value := range row {
+			// e.g. 2/4 or 10M/100M, a dash means that the resource is not limited.
+			used, max, found := strings.Cut(value, "/")
+			if !found {
+				continue
+			}
+			key[len(blimitsConsumers)] = resource
+			v, ok := values[key]
+			if !ok {
+				v = &blimitsValue{}
+				values[key] = v
+			}
+			if usage, ok := ConvertLoadValue(used); ok {
+				v.usage += usage
+				v.hasUsage = true
+			}
+			if limit, ok := ConvertLoadValue(max); ok {
+				if !v.hasLimit || limit > v.limit {
+					v.limit = limit
+				}
+				v.hasLimit = true
+			}
+		}
+	}
+
+	for key, v := range values {
+		if v.hasUsage {
+			ch <- prometheus.MustNewConstMetric(c.LimitUsage, prometheus.GaugeValue, v.usage, key[:]...)
+		}
+		if v.hasLimit {
+			ch <- prometheus.MustNewConstMetric(c.LimitMax, prometheus.GaugeValue, v.limit, key[:]...)
+		}
+	}
+
+	return nil
+}
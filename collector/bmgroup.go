@@ -0,0 +1,280 @@
+package collector
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type bmgroupCollector struct {
+	HostGroupMember        *prometheus.Desc
+	HostGroupRuningJob     *prometheus.Desc
+	HostGroupNJobsCount    *prometheus.Desc
+	HostGroupMaxJobCount   *prometheus.Desc
+	HostGroupSSUSPJobCount *prometheus.Desc
+	HostGroupUSUSPJobCount *prometheus.Desc
+	HostGroupStatusCount   *prometheus.Desc
+	logger                 log.Logger
+}
+
+func init() {
+	registerCollector("bmgroup", false, NewLSFbmgroupCollector)
+}
+
+// NewLSFbmgroupCollector returns a new Collector exposing host group membership
+// and the bhosts slot counters aggregated per host group.
+func NewLSFbmgroupCollector(logger log.Logger) (Collector, error) {
+
+	return &bmgroupCollector{
+		HostGroupMember: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "host_group", "member"),
+			"A metric with a constant '1' value for every host that belongs to a host group, nested groups are expanded.",
+			[]string{"group", "host"}, nil,
+		),
+		HostGroupRuningJob: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "host_group", "runingjob_count"),
+			"The number of tasks for all running jobs on the hosts of the host group.",
+			[]string{"group"}, nil,
+		),
+		HostGroupNJobsCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "host_group", "njobs_count"),
+			"The number of tasks for all jobs that are dispatched to the hosts of the host group.",
+			[]string{"group"}, nil,
+		),
+		HostGroupMaxJobCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "host_group", "maxjob_count"),
+			"The maximum number of job slots available on the hosts of the host group. Hosts without a limit are not counted.",
+			[]string{"group"}, nil,
+		),
+		HostGroupSSUSPJobCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "host_group", "ssuspjob_count"),
+			"The number of tasks for all system suspended jobs on the hosts of the host group.",
+			[]string{"group"}, nil,
+		),
+		HostGroupUSUSPJobCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "host_group", "ususpjob_count"),
+			"The number of tasks for all user suspended jobs on the hosts of the host group.",
+			[]string{"group"}, nil,
+		),
+		HostGroupStatusCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "host_group", "host_status_count"),
+			"The number of hosts of the host group in each bhosts status.",
+			[]string{"group", "status"}, nil,
+		),
+		logger: logger,
+	}, nil
+}
+
+// Update calls (*bmgroupCollector).parseHostGroups to get the host group
+// membership and the aggregated bhosts metrics.
+func (c *bmgroupCollector) Update(ch chan<- prometheus.Metric) error {
+	err := c.parseHostGroups(ch)
+	if err != nil {
+		return fmt.Errorf("couldn't get bmgroup infomation: %w", err)
+	}
+
+	return nil
+}
+
+// bmgroup_ParseOutput parses the output of `bmgroup -w`, the HOSTS column is
+// kept as a list of raw members, e.g. hostA, host[1-10], hg1/ or ~hostB.
+func bmgroup_ParseOutput(lsfOutput []byte) []bmgroupInfo {
+	var groups []bmgroupInfo
+
+	for _, line := range strings.Split(string(lsfOutput), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] == "GROUP_NAME" || fields[0] == "NAME" {
+			continue
+		}
+		if strings.HasPrefix(line, "No ") {
+			continue
+		}
+
+		var hosts []string
+		for _, f := range fields[1:] {
+			f = strings.Trim(f, "()")
+			if f != "" {
+				hosts = append(hosts, f)
+			}
+		}
+		groups = append(groups, bmgroupInfo{GROUP_NAME: fields[0], HOSTS: hosts})
+	}
+	return groups
+}
+
+// expandHostRange expands a condensed host name such as host[01-03,7] into
+// host01, host02, host03 and host7.
+func expandHostRange(name string) []string {
+	start := strings.Index(name, "[")
+	end := strings.Index(name, "]")
+	if start < 0 || end < start {
+		return []string{name}
+	}
+
+	prefix, suffix := name[:start], name[end+1:]
+	var hosts []string
+	for _, part := range strings.Split(name[start+1:end], ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		from, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			hosts = append(hosts, expandHostRange(prefix+part+suffix)...)
+			continue
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, err = strconv.Atoi(bounds[1]); err != nil {
+				continue
+			}
+		}
+		width := 0
+		if len(bounds[0]) > 1 && strings.HasPrefix(bounds[0], "0") {
+			width = len(bounds[0])
+		}
+		for i := from; i <= to; i++ {
+			hosts = append(hosts, expandHostRange(fmt.Sprintf("%s%0*d%s", prefix, width, i, suffix))...)
+		}
+	}
+	return hosts
+}
+
+// expandHostList resolves a list of host group members into host names.
+// Members ending with "/" or naming a known group are expanded recursively,
+// "all" and wildcards are matched against allHosts and members starting
+// with "~" are excluded from the result.
+func expandHostList(members []string, groups map[string][]string, allHosts []string) []string {
+	return expandHostListSeen(members, groups, allHosts, map[string]bool{})
+}
+
+func expandHostListSeen(members []string, groups map[string][]string, allHosts []string, seen map[string]bool) []string {
+	include := map[string]bool{}
+	exclude := map[string]bool{}
+
+	for _, member := range members {
+		target := include
+		if strings.HasPrefix(member, "~") {
+			target = exclude
+			member = strings.TrimPrefix(member, "~")
+		}
+
+		name := strings.TrimSuffix(member, "/")
+		switch {
+		case name == "all":
+			for _, h := range allHosts {
+				target[h] = true
+			}
+		case groups[name] != nil || strings.HasSuffix(member, "/"):
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			for _, h := range expandHostListSeen(groups[name], groups, allHosts, seen) {
+				target[h] = true
+			}
+			delete(seen, name)
+		case strings.ContainsAny(name, "*?"):
+			for _, h := range allHosts {
+				if ok, _ := path.Match(name, h); ok {
+					target[h] = true
+				}
+			}
+		default:
+			for _, h := range expandHostRange(name) {
+				target[h] = true
+			}
+		}
+	}
+
+	var hosts []string
+	for h := range include {
+		if !exclude[h] {
+			hosts = append(hosts, h)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// hostGroupMembers runs `bmgroup -w` and returns the expanded host list of
+// every host group.
+func hostGroupMembers(logger log.Logger, allHosts []string) (map[string][]string, error) {
+	output, err := lsfOutput(logger, "bmgroup", "-w")
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string][]string{}
+	for _, g := range bmgroup_ParseOutput(output) {
+		raw[g.GROUP_NAME] = g.HOSTS
+	}
+
+	members := map[string][]string{}
+	for name := range raw {
+		members[name] = expandHostList([]string{name + "/"}, raw, allHosts)
+	}
+	return members, nil
+}
+
+func (c *bmgroupCollector) parseHostGroups(ch chan<- prometheus.Metric) error {
+	output, err := lsfOutput(c.logger, "bhosts", "-w")
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+	bhosts, err := bhost_CsvtoStruct(output, c.logger)
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+
+	byName := make(map[string]bhostInfo, len(bhosts))
+	allHosts := make([]string, 0, len(bhosts))
+	for _, bhost := range bhosts {
+		byName[bhost.HOST_NAME] = bhost
+		allHosts = append(allHosts, bhost.HOST_NAME)
+	}
+
+	groups, err := hostGroupMembers(c.logger, allHosts)
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+
+	for group, hosts := range groups {
+		var max, njobs, run, ssusp, ususp float64
+		status := map[string]float64{}
+
+		for _, host := range hosts {
+			ch <- prometheus.MustNewConstMetric(c.HostGroupMember, prometheus.GaugeValue, 1, group, host)
+
+			bhost, ok := byName[host]
+			if !ok {
+				continue
+			}
+			if bhost.MAX > 0 {
+				max += bhost.MAX
+			}
+			njobs += bhost.NJOBS
+			run += bhost.RUN
+			ssusp += bhost.SSUSP
+			ususp += bhost.USUSP
+			status[strings.ToLower(bhost.STATUS)]++
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.HostGroupMaxJobCount, prometheus.GaugeValue, max, group)
+		ch <- prometheus.MustNewConstMetric(c.HostGroupNJobsCount, prometheus.GaugeValue, njobs, group)
+		ch <- prometheus.MustNewConstMetric(c.HostGroupRuningJob, prometheus.GaugeValue, run, group)
+		ch <- prometheus.MustNewConstMetric(c.HostGroupSSUSPJobCount, prometheus.GaugeValue, ssusp, group)
+		ch <- prometheus.MustNewConstMetric(c.HostGroupUSUSPJobCount, prometheus.GaugeValue, ususp, group)
+		for s, count := range status {
+			ch <- prometheus.MustNewConstMetric(c.HostGroupStatusCount, prometheus.GaugeValue, count, group, s)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package collector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type computeUnitCollector struct {
+	CUMember    *prometheus.Desc
+	CUStatus    *prometheus.Desc
+	CUMaxSlots  *prometheus.Desc
+	CUUsedSlots *prometheus.Desc
+	CUFreeSlots *prometheus.Desc
+	logger      log.Logger
+}
+
+func init() {
+	registerCollector("compute_unit", false, NewLSFComputeUnitCollector)
+}
+
+// NewLSFComputeUnitCollector returns a new Collector exposing the compute unit
+// topology of `bmgroup -cu` and the slots of the member hosts.
+func NewLSFComputeUnitCollector(logger log.Logger) (Collector, error) {
+
+	return &computeUnitCollector{
+		CUMember: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "compute_unit", "member"),
+			"A metric with a constant '1' value for every host that belongs to a compute unit, nested compute units are expanded.",
+			[]string{"cu", "type", "host"}, nil,
+		),
+		CUStatus: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "compute_unit", "status"),
+			"The status of the compute unit. The status has the following, 0:Unknow, 1:ok, 4:closed, 5:closed_cu_excl",
+			[]string{"cu", "type"}, nil,
+		),
+		CUMaxSlots: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "compute_unit", "maxjob_count"),
+			"The maximum number of job slots available on the hosts of the compute unit. Hosts without a limit are not counted.",
+			[]string{"cu", "type"}, nil,
+		),
+		CUUsedSlots: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "compute_unit", "used_slots"),
+			"The number of tasks for all jobs that are dispatched to the hosts of the compute unit.",
+			[]string{"cu", "type"}, nil,
+		),
+		CUFreeSlots: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "compute_unit", "free_slots"),
+			"The number of job slots that are free on the ok hosts of the compute unit.",
+			[]string{"cu", "type"}, nil,
+		),
+		logger: logger,
+	}, nil
+}
+
+// Update calls (*computeUnitCollector).parseComputeUnits to get the compute
+// unit metrics.
+func (c *computeUnitCollector) Update(ch chan<- prometheus.Metric) error {
+	err := c.parseComputeUnits(ch)
+	if err != nil {
+		return fmt.Errorf("couldn't get compute unit infomation: %w", err)
+	}
+
+	return nil
+}
+
+// bmgroupCU_ParseOutput parses the output of `bmgroup -cu`, the HOSTS column
+// is kept as a list of raw members like in bmgroup_ParseOutput.
+func bmgroupCU_ParseOutput(lsfOutput []byte) []computeUnitInfo {
+	var units []computeUnitInfo
+
+	for _, line := range strings.Split(string(lsfOutput), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] == "NAME" {
+			continue
+		}
+
+		var hosts []string
+		for _, f := range fields[3:] {
+			f = strings.Trim(f, "()")
+			if f != "" {
+				hosts = append(hosts, f)
+			}
+		}
+		units = append(units, computeUnitInfo{NAME: fields[0], TYPE: fields[1], STATUS: fields[2], HOSTS: hosts})
+	}
+	return units
+}
+
+// computeUnits runs `bmgroup -cu` and returns every compute unit with its
+// HOSTS expanded into host names.
+func computeUnits(logger log.Logger, allHosts []string) ([]computeUnitInfo, error) {
+	output, err := lsfOutput(logger, "bmgroup", "-cu")
+	if err != nil {
+		return nil, err
+	}
+
+	units := bmgroupCU_ParseOutput(output)
+	raw := make(map[string][]string, len(units))
+	for _, cu := range units {
+		raw[cu.NAME] = cu.HOSTS
+	}
+	for i := range units {
+		units[i].HOSTS = expandHostList([]string{units[i].NAME + "/"}, raw, allHosts)
+	}
+	return units, nil
+}
+
+// bhostFreeSlots returns the number of free job slots of a host, hosts that
+// are not ok or have no slot limit have no free slots.
+func bhostFreeSlots(bhost bhostInfo) float64 {
+	if strings.ToLower(bhost.STATUS) != "ok" || bhost.MAX <= 0 || bhost.NJOBS >= bhost.MAX {
+		return 0
+	}
+	return bhost.MAX - bhost.NJOBS
+}
+
+func (c *computeUnitCollector) parseComputeUnits(ch chan<- prometheus.Metric) error {
+	output, err := lsfOutput(c.logger, "bhosts", "-w")
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+	bhosts, err := bhost_CsvtoStruct(output, c.logger)
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+
+	byName := make(map[string]bhostInfo, len(bhosts))
+	allHosts := make([]string, 0, len(bhosts))
+	for _, bhost := range bhosts {
+		byName[bhost.HOST_NAME] = bhost
+		allHosts = append(allHosts, bhost.HOST_NAME)
+	}
+
+	units, err := computeUnits(c.logger, allHosts)
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+
+	for _, cu := range units {
+		var max, used, free float64
+		for _, host := range cu.HOSTS {
+			ch <- prometheus.MustNewConstMetric(c.CUMember, prometheus.GaugeValue, 1, cu.NAME, cu.TYPE, host)
+
+			bhost, ok := byName[host]
+			if !ok {
+				continue
+			}
+			if bhost.MAX > 0 {
+				max += bhost.MAX
+			}
+			used += bhost.NJOBS
+			free += bhostFreeSlots(bhost)
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.CUStatus, prometheus.GaugeValue, formatCUStatus(cu.STATUS, c.logger), cu.NAME, cu.TYPE)
+		ch <- prometheus.MustNewConstMetric(c.CUMaxSlots, prometheus.GaugeValue, max, cu.NAME, cu.TYPE)
+		ch <- prometheus.MustNewConstMetric(c.CUUsedSlots, prometheus.GaugeValue, used, cu.NAME, cu.TYPE)
+		ch <- prometheus.MustNewConstMetric(c.CUFreeSlots, prometheus.GaugeValue, free, cu.NAME, cu.TYPE)
+	}
+
+	return nil
+}
+
+// formatCUStatus converts the status of a compute unit like
+// FormatbhostsStatus, closed_cu_excl is only a status of compute units.
+func formatCUStatus(status string, logger log.Logger) float64 {
+	if strings.EqualFold(status, "closed_cu_excl") {
+		return float64(5)
+	}
+	return FormatbhostsStatus(status, logger)
+}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 
+	kingpin "github.com/alecthomas/kingpin/v2"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/jszwec/csvutil"
@@ -20,9 +21,30 @@ type QueuesCollector struct {
 	QueuesMaxJobCount     *prometheus.Desc
 	queuesPriority        *prometheus.Desc
 	QueuesStatus          *prometheus.Desc
-	logger                log.Logger
+	QueuesUserJobLimit    *prometheus.Desc
+	QueuesProcJobLimit    *prometheus.Desc
+	QueuesHostJobLimit    *prometheus.Desc
+	QueuesSuspJobCount    *prometheus.Desc
+	QueuesRsvJobCount     *prometheus.Desc
+
+	QueuesRunLimit       *prometheus.Desc
+	QueuesMemLimit       *prometheus.Desc
+	QueuesProcLimit      *prometheus.Desc
+	QueuesRunWindow      *prometheus.Desc
+	QueuesDispatchWindow *prometheus.Desc
+	QueuesPolicy         *prometheus.Desc
+	QueuesPreemption     *prometheus.Desc
+	QueuesHosts          *prometheus.Desc
+	QueuesUsers          *prometheus.Desc
+	Fairshare            *fairshareMetrics
+	logger               log.Logger
 }
 
+var (
+	bqueuesLong      = kingpin.Flag("collector.bqueues.long", "Also parse `bqueues -l` for the limits, windows, scheduling policies, hosts and users of the queues.").Default("false").Bool()
+	bqueuesFairshare = kingpin.Flag("collector.bqueues.fairshare", "Also parse `bqueues -r` for the fairshare tables of the queues, including the groups of hierarchical fairshare.").Default("false").Bool()
+)
+
 func init() {
 	registerCollector("bqueues", defaultEnabled, NewLSFQueuesCollector)
 }
@@ -56,7 +78,78 @@ func NewLSFQueuesCollector(logger log.Logger) (Collector, error) {
 			"The maximum number of job slots that can be used by the jobs from the queue. These job slots are used by dispatched jobs that are not yet finished, and by pending jobs that reserve slots.			",
 			[]string{"queues_name"}, nil,
 		),
-		logger: logger,
+		QueuesUserJobLimit: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bqueues", "user_job_limit"),
+			"The maximum number of job slots that each user can use in the queue (JL/U). A dash (-1) indicates no limit.",
+			[]string{"queues_name"}, nil,
+		),
+		QueuesProcJobLimit: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bqueues", "processor_job_limit"),
+			"The maximum number of job slots that a processor can process from the queue (JL/P). A dash (-1) indicates no limit.",
+			[]string{"queues_name"}, nil,
+		),
+		QueuesHostJobLimit: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bqueues", "host_job_limit"),
+			"The maximum number of job slots that a host can process from the queue (JL/H). A dash (-1) indicates no limit.",
+			[]string{"queues_name"}, nil,
+		),
+		QueuesSuspJobCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bqueues", "suspjob_count"),
+			"The total number of tasks for all suspended jobs in the queue.",
+			[]string{"queues_name"}, nil,
+		),
+		QueuesRsvJobCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bqueues", "rsvjob_count"),
+			"The total number of tasks for all pending jobs that have slots reserved in the queue.",
+			[]string{"queues_name"}, nil,
+		),
+		QueuesRunLimit: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bqueues", "runlimit_seconds"),
+			"The default and maximum RUNLIMIT of the queue in seconds from bqueues -l. -1 indicates no limit.",
+			[]string{"queues_name", "scope"}, nil,
+		),
+		QueuesMemLimit: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bqueues", "memlimit"),
+			"The default and maximum MEMLIMIT of the queue in KB from bqueues -l. -1 indicates no limit.",
+			[]string{"queues_name", "scope"}, nil,
+		),
+		QueuesProcLimit: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bqueues", "proclimit"),
+			"The minimum, default and maximum PROCLIMIT of the queue from bqueues -l. -1 indicates no limit.",
+			[]string{"queues_name", "bound"}, nil,
+		),
+		QueuesRunWindow: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bqueues", "run_window_info"),
+			"A metric with a constant '1' value labeled by the RUN_WINDOW of the queue from bqueues -l.",
+			[]string{"queues_name", "window"}, nil,
+		),
+		QueuesDispatchWindow: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bqueues", "dispatch_window_info"),
+			"A metric with a constant '1' value labeled by the DISPATCH_WINDOW of the queue from bqueues -l.",
+			[]string{"queues_name", "window"}, nil,
+		),
+		QueuesPolicy: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bqueues", "scheduling_policy_info"),
+			"A metric with a constant '1' value for every SCHEDULING POLICIES entry of the queue from bqueues -l, e.g. FAIRSHARE, PREEMPTIVE or PREEMPTABLE.",
+			[]string{"queues_name", "policy"}, nil,
+		),
+		QueuesPreemption: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bqueues", "preemption_info"),
+			"A metric with a constant '1' value labeled by the PREEMPTION setting of the queue from bqueues -l.",
+			[]string{"queues_name", "preemption"}, nil,
+		),
+		QueuesHosts: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bqueues", "hosts_info"),
+			"A metric with a constant '1' value labeled by the HOSTS list of the queue from bqueues -l.",
+			[]string{"queues_name", "hosts"}, nil,
+		),
+		QueuesUsers: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bqueues", "users_info"),
+			"A metric with a constant '1' value labeled by the USERS list of the queue from bqueues -l.",
+			[]string{"queues_name", "users"}, nil,
+		),
+		Fairshare: newFairshareMetrics(),
+		logger:    logger,
 	}, nil
 }
 
@@ -73,6 +166,20 @@ func (c *QueuesCollector) Update(ch chan<- prometheus.Metric) error {
 		return fmt.Errorf("couldn't get queues infomation: %w", err)
 	}
 
+	if *bqueuesLong {
+		err = c.parseQueuesLong(ch)
+		if err != nil {
+			return fmt.Errorf("couldn't get bqueues -l infomation: %w", err)
+		}
+	}
+
+	if *bqueuesFairshare {
+		err = c.parseQueuesFairshare(ch)
+		if err != nil {
+			return fmt.Errorf("couldn't get bqueues -r infomation: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -122,6 +229,16 @@ func FormatQueusStatus(status string, logger log.Logger) float64 {
 	}
 }
 
+// FormatQueueLimit converts a bqueues limit into a float64, a dash or any
+// other unlimited value is converted into -1.
+func FormatQueueLimit(limit string) float64 {
+	value, err := strconv.ParseFloat(limit, 64)
+	if err != nil {
+		return -1
+	}
+	return value
+}
+
 func (c *QueuesCollector) parseQueuesJobCount(ch chan<- prometheus.Metric) error {
 	output, err := lsfOutput(c.logger, "bqueues", "-w")
 	if err != nil {
@@ -144,7 +261,285 @@ func (c *QueuesCollector) parseQueuesJobCount(ch chan<- prometheus.Metric) error
 		ch <- prometheus.MustNewConstMetric(c.QueuesMaxJobCount, prometheus.GaugeValue, MAXCount, q.QUEUE_NAME)
 		ch <- prometheus.MustNewConstMetric(c.queuesPriority, prometheus.GaugeValue, q.PRIO, q.QUEUE_NAME)
 		ch <- prometheus.MustNewConstMetric(c.QueuesStatus, prometheus.GaugeValue, FormatQueusStatus(q.STATUS, c.logger), q.QUEUE_NAME)
+		ch <- prometheus.MustNewConstMetric(c.QueuesUserJobLimit, prometheus.GaugeValue, FormatQueueLimit(q.JL_U), q.QUEUE_NAME)
+		ch <- prometheus.MustNewConstMetric(c.QueuesProcJobLimit, prometheus.GaugeValue, FormatQueueLimit(q.JL_P), q.QUEUE_NAME)
+		ch <- prometheus.MustNewConstMetric(c.QueuesHostJobLimit, prometheus.GaugeValue, FormatQueueLimit(q.JL_H), q.QUEUE_NAME)
+		// SUSP and RSV are job counts, not limits.
+		if suspCount, err := strconv.ParseFloat(q.SUSP, 64); err == nil {
+			ch <- prometheus.MustNewConstMetric(c.QueuesSuspJobCount, prometheus.GaugeValue, suspCount, q.QUEUE_NAME)
+		}
+		if rsvCount, err := strconv.ParseFloat(q.RSV, 64); err == nil {
+			ch <- prometheus.MustNewConstMetric(c.QueuesRsvJobCount, prometheus.GaugeValue, rsvCount, q.QUEUE_NAME)
+		}
+	}
+
+	return nil
+}
+
+// queueLimitUnits are the units that bqueues -l prints after a limit value.
+var queueLimitUnits = map[string]bool{
+	"K": true, "KB": true, "M": true, "MB": true, "G": true, "GB": true,
+	"T": true, "TB": true, "P": true, "PB": true, "E": true, "EB": true, "min": true,
+}
+
+// bqueuesLimitValues splits a limit line of bqueues -l, e.g.
+// "720.0 min of hostA   20000 K", into "720.0 min" and "20000 K".
+func bqueuesLimitValues(line string) []string {
+	fields := strings.Fields(line)
+	var values []string
+	for i := 0; i < len(fields); i++ {
+		switch {
+		case fields[i] == "of" && i+1 < len(fields):
+			i++
+		case queueLimitUnits[fields[i]] && len(values) > 0:
+			values[len(values)-1] += " " + fields[i]
+		default:
+			values = append(values, fields[i])
+		}
+	}
+	return values
+}
+
+// FormatQueueRunLimit converts a RUNLIMIT of bqueues -l such as "720.0 min"
+// into seconds, an unlimited value is converted into -1.
+func FormatQueueRunLimit(limit string) float64 {
+	fields := strings.Fields(limit)
+	if len(fields) == 0 {
+		return -1
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return -1
+	}
+	return value * 60
+}
+
+// FormatQueueMemLimit converts a MEMLIMIT of bqueues -l such as "1 G" into
+// KB, an unlimited value is converted into -1.
+func FormatQueueMemLimit(limit string) float64 {
+	fields := strings.Fields(limit)
+	if len(fields) == 0 {
+		return -1
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return -1
+	}
+	unit := "K"
+	if len(fields) > 1 {
+		unit = strings.ToUpper(fields[1][:1])
+	}
+	return FormatlshostsUnit(value, unit)
+}
+
+// bqueuesLong_ParseOutput parses the output of `bqueues -l`.
+func bqueuesLong_ParseOutput(lsfOutput []byte) []bqueuesLongInfo {
+	var queues []bqueuesLongInfo
+	cur := -1
+	scope := "maximum"
+
+	lines := strings.Split(string(lsfOutput), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if strings.HasPrefix(line, "QUEUE:") {
+			queues = append(queues, bqueuesLongInfo{
+				QUEUE_NAME: strings.TrimSpace(strings.TrimPrefix(line, "QUEUE:")),
+				LIMITS:     map[string]map[string][]string{},
+			})
+			cur = len(queues) - 1
+			scope = "maximum"
+			continue
+		}
+		if cur < 0 {
+			continue
+		}
+		q := &queues[cur]
+
+		switch {
+		case line == "DEFAULT LIMITS:":
+			scope = "default"
+		case line == "MAXIMUM LIMITS:":
+			scope = "maximum"
+		case isQueueLimitHeader(fields) && i+1 < len(lines):
+			values := bqueuesLimitValues(lines[i+1])
+			if q.LIMITS[scope] == nil {
+				q.LIMITS[scope] = map[string][]string{}
+			}
+			if len(fields) == 1 {
+				q.LIMITS[scope][fields[0]] = values
+			} else {
+				for j, name := range fields {
+					if j < len(values) {
+						q.LIMITS[scope][name] = []string{values[j]}
+					}
+				}
+			}
+			i++
+		case strings.HasPrefix(line, "SCHEDULING POLICIES:"):
+			q.POLICIES = strings.Fields(strings.TrimPrefix(line, "SCHEDULING POLICIES:"))
+		case strings.HasPrefix(line, "PREEMPTION"):
+			q.PREEMPTION = strings.TrimSpace(strings.TrimLeft(strings.TrimPrefix(line, "PREEMPTION"), " =:"))
+		case strings.HasPrefix(line, "USERS:"):
+			q.USERS = strings.Join(strings.Fields(strings.TrimPrefix(line, "USERS:")), " ")
+		case strings.HasPrefix(line, "HOSTS:"):
+			q.HOSTS = strings.Join(strings.Fields(strings.TrimPrefix(line, "HOSTS:")), " ")
+		case strings.HasPrefix(line, "RUN_WINDOW"):
+			q.RUN_WINDOW = strings.TrimSpace(line[strings.Index(line, ":")+1:])
+		case strings.HasPrefix(line, "DISPATCH_WINDOW"):
+			q.DISPATCH_WINDOW = strings.TrimSpace(line[strings.Index(line, ":")+1:])
+		}
 	}
+	return queues
+}
+
+// isQueueLimitHeader reports whether a line of bqueues -l only holds limit
+// names, e.g. "RUNLIMIT" or "MEMLIMIT SWAPLIMIT PROCESSLIMIT".
+func isQueueLimitHeader(fields []string) bool {
+	for _, f := range fields {
+		if !strings.HasSuffix(f, "LIMIT") || strings.ToUpper(f) != f {
+			return false
+		}
+	}
+	return len(fields) > 0
+}
+
+// parseQueuesFairshare exports the fairshare tables of `bqueues -r`, which
+// unlike -l also prints the share information of every group of a
+// hierarchical fairshare tree.
+func (c *QueuesCollector) parseQueuesFairshare(ch chan<- prometheus.Metric) error {
+	output, err := lsfOutput(c.logger, "bqueues", "-r")
+	if err != nil {
+		level.Error(c.logger).Log("err=", err)
+		return nil
+	}
+
+	c.Fairshare.collect(ch, "queue", fairshare_ParseOutput(output))
 
 	return nil
 }
+
+func (c *QueuesCollector) parseQueuesLong(ch chan<- prometheus.Metric) error {
+	output, err := lsfOutput(c.logger, "bqueues", "-l")
+	if err != nil {
+		level.Error(c.logger).Log("err=", err)
+		return nil
+	}
+
+	for _, q := range bqueuesLong_ParseOutput(output) {
+		for _, scope := range []string{"default", "maximum"} {
+			runLimit, memLimit := float64(-1), float64(-1)
+			if values := q.LIMITS[scope]["RUNLIMIT"]; len(values) > 0 {
+				runLimit = FormatQueueRunLimit(values[0])
+			}
+			if values := q.LIMITS[scope]["MEMLIMIT"]; len(values) > 0 {
+				memLimit = FormatQueueMemLimit(values[0])
+			}
+			ch <- prometheus.MustNewConstMetric(c.QueuesRunLimit, prometheus.GaugeValue, runLimit, q.QUEUE_NAME, scope)
+			ch <- prometheus.MustNewConstMetric(c.QueuesMemLimit, prometheus.GaugeValue, memLimit, q.QUEUE_NAME, scope)
+		}
+
+		// PROCLIMIT is printed as [minimum [default]] maximum.
+		procLimit := map[string]float64{"minimum": -1, "default": -1, "maximum": -1}
+		values := q.LIMITS["maximum"]["PROCLIMIT"]
+		switch len(values) {
+		case 1:
+			procLimit["maximum"] = FormatQueueLimit(values[0])
+		case 2:
+			procLimit["minimum"] = FormatQueueLimit(values[0])
+			procLimit["maximum"] = FormatQueueLimit(values[1])
+		case 3:
+			procLimit["minimum"] = FormatQueueLimit(values[0])
+			procLimit["default"] = FormatQueueLimit(values[1])
+			procLimit["maximum"] = FormatQueueLimit(values[2])
+		}
+		for bound, value := range procLimit {
+			ch <- prometheus.MustNewConstMetric(c.QueuesProcLimit, prometheus.GaugeValue, value, q.QUEUE_NAME, bound)
+		}
+
+		if q.RUN_WINDOW != "" {
+			ch <- prometheus.MustNewConstMetric(c.QueuesRunWindow, prometheus.GaugeValue, 1, q.QUEUE_NAME, q.RUN_WINDOW)
+		}
+		if q.DISPATCH_WINDOW != "" {
+			ch <- prometheus.MustNewConstMetric(c.QueuesDispatchWindow, prometheus.GaugeValue, 1, q.QUEUE_NAME, q.DISPATCH_WINDOW)
+		}
+		for _, policy := range q.POLICIES {
+			ch <- prometheus.MustNewConstMetric(c.QueuesPolicy, prometheus.GaugeValue, 1, q.QUEUE_NAME, policy)
+		}
+		if q.PREEMPTION != "" {
+			ch <- prometheus.MustNewConstMetric(c.QueuesPreemption, prometheus.GaugeValue, 1, q.QUEUE_NAME, q.PREEMPTION)
+		}
+		ch <- prometheus.MustNewConstMetric(c.QueuesHosts, prometheus.GaugeValue, 1, q.QUEUE_NAME, q.HOSTS)
+		ch <- prometheus.MustNewConstMetric(c.QueuesUsers, prometheus.GaugeValue, 1, q.QUEUE_NAME, q.USERS)
+	}
+
+	return nil
+}
+
+// queueHosts runs `bqueues -l` and returns the hosts eligible for every queue,
+// resolved from the HOSTS parameter of the queue.
+func queueHosts(logger log.Logger, allHosts []string) (map[string][]string, error) {
+	output, err := lsfOutput(logger, "bqueues", "-l")
+	if err != nil {
+		return nil, err
+	}
+	groups, err := hostGroupMembers(logger, allHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := map[string][]string{}
+	for _, q := range bqueuesLong_ParseOutput(output) {
+		hosts[q.QUEUE_NAME] = resolveQueueHosts(q.HOSTS, groups, allHosts)
+	}
+	return hosts, nil
+}
+
+// resolveQueueHosts expands the HOSTS parameter of a queue into host names.
+// others stands for the hosts that are not listed explicitly, the host
+// preferences like hostA+2 are ignored.
+func resolveQueueHosts(hosts string, groups map[string][]string, allHosts []string) []string {
+	// A queue without HOSTS uses all the hosts of the cluster.
+	if hosts == "" || strings.HasPrefix(hosts, "all hosts") {
+		return allHosts
+	}
+
+	var members []string
+	hasOthers := false
+	for _, member := range strings.Fields(hosts) {
+		if i := strings.LastIndex(member, "+"); i > 0 {
+			if _, err := strconv.Atoi(member[i+1:]); err == nil {
+				member = member[:i]
+			}
+		}
+		switch member {
+		case "others":
+			hasOthers = true
+		case "none":
+		default:
+			members = append(members, member)
+		}
+	}
+
+	if hasOthers {
+		listed := map[string]bool{}
+		for _, member := range members {
+			if strings.HasPrefix(member, "~") {
+				continue
+			}
+			for _, h := range expandHostList([]string{member}, groups, allHosts) {
+				listed[h] = true
+			}
+		}
+		for _, h := range allHosts {
+			if !listed[h] {
+				members = append(members, h)
+			}
+		}
+	}
+	return expandHostList(members, groups, allHosts)
+}
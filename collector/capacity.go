@@ -0,0 +1,191 @@
+package collector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// capacityMetric is exported once for the whole cluster and once per host
+// model.
+type capacityMetric struct {
+	cluster *prometheus.Desc
+	model   *prometheus.Desc
+}
+
+func newCapacityMetric(name, help string, labels ...string) capacityMetric {
+	return capacityMetric{
+		cluster: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "capacity", name),
+			help+" Summed over the cluster.",
+			labels, nil,
+		),
+		model: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "capacity_model", name),
+			help+" Summed per host model.",
+			append([]string{"host_model"}, labels...), nil,
+		),
+	}
+}
+
+type capacityCollector struct {
+	Cores          capacityMetric
+	Slots          capacityMetric
+	AllocatedSlots capacityMetric
+	RunningSlots   capacityMetric
+	BusyCores      capacityMetric
+	Memory         capacityMetric
+	FreeMemory     capacityMetric
+	HostStatus     capacityMetric
+	logger         log.Logger
+}
+
+func init() {
+	registerCollector("capacity", false, NewLSFCapacityCollector)
+}
+
+// NewLSFCapacityCollector returns a new Collector exposing the cluster capacity
+// joined from lshosts, bhosts and lsload.
+func NewLSFCapacityCollector(logger log.Logger) (Collector, error) {
+
+	return &capacityCollector{
+		Cores:          newCapacityMetric("cores", "The number of installed cores (ncpus of lshosts) of the batch hosts."),
+		Slots:          newCapacityMetric("slots", "The number of configured job slots (MAX of bhosts) of the batch hosts. Hosts without a limit are not counted."),
+		AllocatedSlots: newCapacityMetric("allocated_slots", "The number of job slots allocated to running, suspended and chunk jobs (NJOBS of bhosts)."),
+		RunningSlots:   newCapacityMetric("running_slots", "The number of job slots of running jobs (RUN of bhosts)."),
+		BusyCores:      newCapacityMetric("busy_cores", "The number of cores that are actually busy, the CPU utilization (ut of lsload) multiplied by the installed cores."),
+		Memory:         newCapacityMetric("memory", "The installed memory (maxmem of lshosts) of the batch hosts in KB."),
+		FreeMemory:     newCapacityMetric("free_memory", "The available memory (mem of lsload) of the batch hosts in KB."),
+		HostStatus:     newCapacityMetric("hosts", "The number of batch hosts in each bhosts status.", "status"),
+		logger:         logger,
+	}, nil
+}
+
+// Update calls (*capacityCollector).parseCapacity to get the capacity metrics.
+func (c *capacityCollector) Update(ch chan<- prometheus.Metric) error {
+	err := c.parseCapacity(ch)
+	if err != nil {
+		return fmt.Errorf("couldn't get capacity infomation: %w", err)
+	}
+
+	return nil
+}
+
+// hostCapacities runs `bhosts -w`, `lshosts -w` and `lsload -w` and joins
+// their output per batch host.
+func hostCapacities(logger log.Logger) (map[string]*hostCapacity, error) {
+	output, err := lsfOutput(logger, "bhosts", "-w")
+	if err != nil {
+		return nil, err
+	}
+	bhosts, err := bhost_CsvtoStruct(output, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make(map[string]*hostCapacity, len(bhosts))
+	for _, bhost := range bhosts {
+		hosts[bhost.HOST_NAME] = &hostCapacity{
+			HOST_NAME: bhost.HOST_NAME,
+			MODEL:     "unknown",
+			STATUS:    strings.ToLower(bhost.STATUS),
+			MAX:       bhost.MAX,
+			NJOBS:     bhost.NJOBS,
+			RUN:       bhost.RUN,
+		}
+	}
+
+	output, err = lsfOutput(logger, "lshosts", "-w")
+	if err != nil {
+		return nil, err
+	}
+	for _, lshost := range lsload_ParseOutput(output) {
+		host, ok := hosts[lshost["HOST_NAME"]]
+		if !ok {
+			continue
+		}
+		host.MODEL = lshost["model"]
+		host.NCPUS, _ = ConvertLoadValue(lshost["ncpus"])
+		host.MAXMEM, _ = ConvertLoadValue(lshost["maxmem"])
+	}
+
+	output, err = lsfOutput(logger, "lsload", "-w")
+	if err != nil {
+		return nil, err
+	}
+	for _, lsload := range lsload_ParseOutput(output) {
+		host, ok := hosts[lsload["HOST_NAME"]]
+		if !ok {
+			continue
+		}
+		ut, utOk := ConvertLoadValue(lsload["ut"])
+		mem, memOk := ConvertLoadValue(lsload["mem"])
+		host.R1M, _ = ConvertLoadValue(lsload["r1m"])
+		host.HAS_LOAD = utOk && memOk
+		host.UT = ut / 100
+		host.MEM = mem
+	}
+
+	return hosts, nil
+}
+
+func (c *capacityCollector) parseCapacity(ch chan<- prometheus.Metric) error {
+	hosts, err := hostCapacities(c.logger)
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+
+	type totals struct {
+		cores, slots, allocated, running, busy, memory, free float64
+		status                                               map[string]float64
+	}
+	cluster := &totals{status: map[string]float64{}}
+	models := map[string]*totals{}
+
+	for _, host := range hosts {
+		model, ok := models[host.MODEL]
+		if !ok {
+			model = &totals{status: map[string]float64{}}
+			models[host.MODEL] = model
+		}
+
+		for _, t := range []*totals{cluster, model} {
+			t.cores += host.NCPUS
+			if host.MAX > 0 {
+				t.slots += host.MAX
+			}
+			t.allocated += host.NJOBS
+			t.running += host.RUN
+			t.memory += host.MAXMEM
+			if host.HAS_LOAD {
+				t.busy += host.UT * host.NCPUS
+				t.free += host.MEM
+			}
+			t.status[host.STATUS]++
+		}
+	}
+
+	emit := func(t *totals, desc func(capacityMetric) *prometheus.Desc, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc(c.Cores), prometheus.GaugeValue, t.cores, labels...)
+		ch <- prometheus.MustNewConstMetric(desc(c.Slots), prometheus.GaugeValue, t.slots, labels...)
+		ch <- prometheus.MustNewConstMetric(desc(c.AllocatedSlots), prometheus.GaugeValue, t.allocated, labels...)
+		ch <- prometheus.MustNewConstMetric(desc(c.RunningSlots), prometheus.GaugeValue, t.running, labels...)
+		ch <- prometheus.MustNewConstMetric(desc(c.BusyCores), prometheus.GaugeValue, t.busy, labels...)
+		ch <- prometheus.MustNewConstMetric(desc(c.Memory), prometheus.GaugeValue, t.memory, labels...)
+		ch <- prometheus.MustNewConstMetric(desc(c.FreeMemory), prometheus.GaugeValue, t.free, labels...)
+		for status, count := range t.status {
+			ch <- prometheus.MustNewConstMetric(desc(c.HostStatus), prometheus.GaugeValue, count, append(labels, status)...)
+		}
+	}
+
+	emit(cluster, func(m capacityMetric) *prometheus.Desc { return m.cluster })
+	for name, model := range models {
+		emit(model, func(m capacityMetric) *prometheus.Desc { return m.model }, name)
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	kingpin "github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	chargebackRunning = kingpin.Flag("collector.lsbacct.chargeback-running", "Also accrue the chargeback counters from the elapsed time of the running jobs of `bjobs -r`, the finished job record then only adds the rest of the job.").Default("false").Bool()
+)
+
+// chargebackKey are the labels of the chargeback counters.
+type chargebackKey struct {
+	project, user, userGroup, queue string
+}
+
+// chargebackUsage is the usage of a job charged to its project.
+type chargebackUsage struct {
+	slotSeconds, cpuSeconds, gpuSeconds, memoryGBSeconds float64
+}
+
+// sub returns the usage that is not accrued yet, a negative difference
+// counts as 0 to keep the counters monotonic.
+func (u chargebackUsage) sub(accrued chargebackUsage) chargebackUsage {
+	return chargebackUsage{
+		slotSeconds:     math.Max(0, u.slotSeconds-accrued.slotSeconds),
+		cpuSeconds:      math.Max(0, u.cpuSeconds-accrued.cpuSeconds),
+		gpuSeconds:      math.Max(0, u.gpuSeconds-accrued.gpuSeconds),
+		memoryGBSeconds: math.Max(0, u.memoryGBSeconds-accrued.memoryGBSeconds),
+	}
+}
+
+// runningCharge is the usage already charged for a running job.
+type runningCharge struct {
+	usage    chargebackUsage
+	lastSeen time.Time
+}
+
+// chargeback accumulates the slot, CPU, GPU and memory seconds of the jobs
+// per project, user, user group and queue.
+type chargeback struct {
+	SlotSeconds     *prometheus.Desc
+	CpuSeconds      *prometheus.Desc
+	GpuSeconds      *prometheus.Desc
+	MemoryGBSeconds *prometheus.Desc
+	usage           map[chargebackKey]*chargebackUsage
+	running         map[string]*runningCharge
+}
+
+func newChargeback() *chargeback {
+	labels := []string{"project", "user", "user_group", "queue"}
+	return &chargeback{
+		SlotSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lsbacct", "slot_seconds_total"),
+			"The job slots multiplied by the run time of the jobs.",
+			labels, nil,
+		),
+		CpuSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lsbacct", "cpu_seconds_total"),
+			"The user and system CPU time of the jobs.",
+			labels, nil,
+		),
+		GpuSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lsbacct", "gpu_seconds_total"),
+			"The GPUs requested by the jobs multiplied by their run time.",
+			labels, nil,
+		),
+		MemoryGBSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lsbacct", "memory_gb_seconds_total"),
+			"The maximum resident memory of the jobs in GB multiplied by their run time.",
+			labels, nil,
+		),
+		usage:   map[chargebackKey]*chargebackUsage{},
+		running: map[string]*runningCharge{},
+	}
+}
+
+// chargeUserGroup returns the user group of the fairshare path a job is
+// charged to, e.g. groupA for /groupA/user1.
+func chargeUserGroup(saap string) string {
+	path := strings.Split(strings.Trim(saap, "/"), "/")
+	if len(path) < 2 {
+		return "default"
+	}
+	return path[len(path)-2]
+}
+
+func (c *chargeback) add(key chargebackKey, u chargebackUsage) {
+	total, ok := c.usage[key]
+	if !ok {
+		total = &chargebackUsage{}
+		c.usage[key] = total
+	}
+	total.slotSeconds += u.slotSeconds
+	total.cpuSeconds += u.cpuSeconds
+	total.gpuSeconds += u.gpuSeconds
+	total.memoryGBSeconds += u.memoryGBSeconds
+}
+
+// finish charges the usage of a finished job that was not accrued while the
+// job was running.
+func (c *chargeback) finish(job lsbAcctJob) {
+	var runTime float64
+	if job.START_TIME > 0 {
+		runTime = math.Max(0, job.END_TIME-job.START_TIME)
+	}
+	gpus := rusageGpus("", parseRusage(job.RES_REQ))
+	u := chargebackUsage{
+		slotSeconds:     job.NUM_PROCESSORS * runTime,
+		cpuSeconds:      job.CPU_TIME,
+		gpuSeconds:      gpus * runTime,
+		memoryGBSeconds: math.Max(0, job.MAX_MEM) / 1024 / 1024 * runTime,
+	}
+	if accrued, ok := c.running[job.JOB_ID]; ok {
+		u = u.sub(accrued.usage)
+		delete(c.running, job.JOB_ID)
+	}
+	c.add(chargebackKey{job.PROJECT, job.USER, chargeUserGroup(job.CHARGED_SAAP), job.QUEUE}, u)
+}
+
+// lsfJobKey returns the key of a job, id[index] for an element of a job
+// array, as the job ID of lsbAcctJob.
+func lsfJobKey(id string, idx int64) string {
+	if idx > 0 {
+		return fmt.Sprintf("%s[%d]", id, idx)
+	}
+	return id
+}
+
+// bjobsSeconds parses a time of bjobs, e.g. 123 second(s).
+func bjobsSeconds(value string) float64 {
+	v, _ := strconv.ParseFloat(strings.TrimSuffix(value, " second(s)"), 64)
+	return v
+}
+
+// accrue charges the usage of the running jobs since the previous scrape.
+func (c *chargeback) accrue(logger log.Logger) error {
+	output, err := lsfOutput(logger, "bjobs", "-u", "all", "-r", "-o", "jobid jobindex queue user proj_name charged_saap nalloc_slot run_time cpu_used max_mem gpu_num combined_resreq", "-json")
+	if err != nil {
+		return err
+	}
+	return c.accrueJobs(output, time.Now())
+}
+
+// accrueJobs charges the usage of the running jobs of `bjobs -json`. The
+// elements of a job array share the JOBID and are told apart by JOBINDEX.
+func (c *chargeback) accrueJobs(output []byte, now time.Time) error {
+	var jobs bjobsJSON
+	if err := json.Unmarshal(output, &jobs); err != nil {
+		return err
+	}
+
+	for _, job := range jobs.RECORDS {
+		if job["JOBID"] == "" {
+			continue
+		}
+		idx, _ := strconv.ParseInt(job["JOBINDEX"], 10, 64)
+		id := lsfJobKey(job["JOBID"], idx)
+		slots, _ := strconv.ParseFloat(job["NALLOC_SLOT"], 64)
+		runTime := bjobsSeconds(job["RUN_TIME"])
+		memory, _ := ConvertLoadValue(strings.ReplaceAll(strings.TrimSuffix(job["MAX_MEM"], "bytes"), " ", ""))
+		gpus := rusageGpus(job["GPU_NUM"], parseRusage(job["COMBINED_RESREQ"]))
+		u := chargebackUsage{
+			slotSeconds:     slots * runTime,
+			cpuSeconds:      bjobsSeconds(job["CPU_USED"]),
+			gpuSeconds:      gpus * runTime,
+			memoryGBSeconds: memory / 1024 / 1024 * runTime,
+		}
+
+		charge, ok := c.running[id]
+		if !ok {
+			charge = &runningCharge{}
+			c.running[id] = charge
+		}
+		project := job["PROJ_NAME"]
+		if project == "" || project == "-" {
+			project = "default"
+		}
+		c.add(chargebackKey{project, job["USER"], chargeUserGroup(job["CHARGED_SAAP"]), job["QUEUE"]}, u.sub(charge.usage))
+		charge.usage.slotSeconds = math.Max(charge.usage.slotSeconds, u.slotSeconds)
+		charge.usage.cpuSeconds = math.Max(charge.usage.cpuSeconds, u.cpuSeconds)
+		charge.usage.gpuSeconds = math.Max(charge.usage.gpuSeconds, u.gpuSeconds)
+		charge.usage.memoryGBSeconds = math.Max(charge.usage.memoryGBSeconds, u.memoryGBSeconds)
+		charge.lastSeen = now
+	}
+
+	// Forget the jobs whose finished record was never read.
+	for id, charge := range c.running {
+		if now.Sub(charge.lastSeen) > 24*time.Hour {
+			delete(c.running, id)
+		}
+	}
+	return nil
+}
+
+func (c *chargeback) collect(ch chan<- prometheus.Metric) {
+	for key, u := range c.usage {
+		labels := []string{key.project, key.user, key.userGroup, key.queue}
+		ch <- prometheus.MustNewConstMetric(c.SlotSeconds, prometheus.CounterValue, u.slotSeconds, labels...)
+		ch <- prometheus.MustNewConstMetric(c.CpuSeconds, prometheus.CounterValue, u.cpuSeconds, labels...)
+		ch <- prometheus.MustNewConstMetric(c.GpuSeconds, prometheus.CounterValue, u.gpuSeconds, labels...)
+		ch <- prometheus.MustNewConstMetric(c.MemoryGBSeconds, prometheus.CounterValue, u.memoryGBSeconds, labels...)
+	}
+}
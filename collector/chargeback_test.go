@@ -0,0 +1,42 @@
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChargebackArrayJob(t *testing.T) {
+	c := newChargeback()
+
+	// Two running elements of job array 202, one minute after their start.
+	bjobs := []byte(`{
+  "COMMAND":"bjobs",
+  "JOBS":2,
+  "RECORDS":[
+    {"JOBID":"202","JOBINDEX":"3","QUEUE":"short","USER":"bob","PROJ_NAME":"default","CHARGED_SAAP":"\/bob","NALLOC_SLOT":"1","RUN_TIME":"60 second(s)","CPU_USED":"1.0 second(s)","MAX_MEM":"10 Mbytes","GPU_NUM":"","COMBINED_RESREQ":""},
+    {"JOBID":"202","JOBINDEX":"4","QUEUE":"short","USER":"bob","PROJ_NAME":"default","CHARGED_SAAP":"\/bob","NALLOC_SLOT":"1","RUN_TIME":"60 second(s)","CPU_USED":"1.0 second(s)","MAX_MEM":"10 Mbytes","GPU_NUM":"","COMBINED_RESREQ":""}
+  ]
+}`)
+	if err := c.accrueJobs(bjobs, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	key := chargebackKey{"default", "bob", "default", "short"}
+	if got := c.usage[key].slotSeconds; got != 120 {
+		t.Errorf("slot seconds of the running elements: got %v, want 120", got)
+	}
+
+	// 202[3] of fixtures/lsb.acct ran 100 seconds, 60 of them were accrued.
+	c.finish(readJobFinishFixture(t)[1])
+	if got := c.usage[key].slotSeconds; got != 160 {
+		t.Errorf("slot seconds after 202[3] finished: got %v, want 160", got)
+	}
+	if got := c.usage[key].cpuSeconds; got != 2.5 {
+		t.Errorf("CPU seconds after 202[3] finished: got %v, want 2.5", got)
+	}
+	if _, ok := c.running["202[3]"]; ok {
+		t.Errorf("finished element 202[3] is still accrued")
+	}
+	if _, ok := c.running["202[4]"]; !ok {
+		t.Errorf("running element 202[4] is not accrued")
+	}
+}
@@ -48,6 +48,16 @@ var (
 		[]string{"collector", "name"},
 		nil,
 	)
+	lsfCommandDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "command",
+			Name:      "duration_seconds",
+			Help:      "lsf_exporter: Wall-clock duration of the LSF commands run by the collectors.",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 40, 60},
+		},
+		[]string{"command"},
+	)
 )
 
 const (
@@ -154,6 +164,7 @@ func (n LsfCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- scrapeDurationDesc
 	ch <- scrapeSuccessDesc
 	ch <- scrapeErrorDesc
+	lsfCommandDuration.Describe(ch)
 }
 
 // Collect implements the prometheus.Collector interface.
@@ -170,6 +181,7 @@ func (n LsfCollector) Collect(ch chan<- prometheus.Metric) {
 	}
 
 	wg.Wait()
+	lsfCommandDuration.Collect(ch)
 }
 
 func execute(name string, c Collector, ch chan<- prometheus.Metric, logger log.Logger) {
@@ -0,0 +1,209 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	kingpin "github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	daemonLogFiles        = kingpin.Flag("collector.daemon_log.file", "Daemon log file to scan, the daemon is the part of the file name before .log. Can be repeated, defaults to the mbatchd, mbschd, lim, sbatchd and res logs of this host in $LSF_LOGDIR.").Strings()
+	daemonLogLevel        = kingpin.Flag("collector.daemon_log.level", "Count the lines of this level and the more severe levels.").Default("LOG_WARNING").Enum("LOG_EMERG", "LOG_ALERT", "LOG_CRIT", "LOG_ERR", "LOG_WARNING", "LOG_NOTICE", "LOG_INFO", "LOG_DEBUG")
+	daemonLogMaxTemplates = kingpin.Flag("collector.daemon_log.max-templates", "Maximum number of message templates per daemon, the lines of further templates are counted as other.").Default("500").Int()
+)
+
+// daemonLogLevels are the names of the levels of the daemon log lines.
+var daemonLogLevels = []string{"LOG_EMERG", "LOG_ALERT", "LOG_CRIT", "LOG_ERR", "LOG_WARNING", "LOG_NOTICE", "LOG_INFO", "LOG_DEBUG"}
+
+// daemonLogHostsRefresh is the interval of the refresh of the host names
+// removed from the message templates.
+const daemonLogHostsRefresh = time.Hour
+
+// daemonLogTemplate returns the message of a daemon log line without the
+// values that change from line to line. The words are replaced if they are
+// one of the hosts, with or without the domain, or contain digits.
+func daemonLogTemplate(message string, hosts map[string]bool) string {
+	message = LogBracketRegex.ReplaceAllString(message, "<*>")
+	message = LogWordRegex.ReplaceAllStringFunc(message, func(word string) string {
+		short, _, _ := strings.Cut(strings.TrimSuffix(word, "."), ".")
+		if hosts[word] || hosts[short] || strings.ContainsAny(word, "0123456789") {
+			return "*"
+		}
+		return word
+	})
+	return strings.Join(strings.Fields(message), " ")
+}
+
+// daemonLogHosts returns the host names of lshosts and of this host, with
+// and without the domain.
+func daemonLogHosts(logger log.Logger) (map[string]bool, error) {
+	output, err := lsfOutput(logger, "lshosts", "-w")
+	if err != nil {
+		return nil, err
+	}
+	lshosts, err := lshosts_CsvtoStruct(output, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(lshosts)+1)
+	for _, h := range lshosts {
+		names = append(names, h.HOST_NAME)
+	}
+	if host, err := os.Hostname(); err == nil {
+		names = append(names, host)
+	}
+	hosts := make(map[string]bool, 2*len(names))
+	for _, name := range names {
+		short, _, _ := strings.Cut(name, ".")
+		hosts[name] = true
+		hosts[short] = true
+	}
+	return hosts, nil
+}
+
+// daemonLogKey are the labels of the daemon log line counter.
+type daemonLogKey struct {
+	daemon, level, template string
+}
+
+type daemonLogCollector struct {
+	Lines     *prometheus.Desc
+	tailers   []*fileTailer
+	lines     map[daemonLogKey]float64
+	templates map[string]map[string]bool
+	// hosts are the host names removed from the templates, refreshed every
+	// daemonLogHostsRefresh.
+	hosts          map[string]bool
+	hostsRefreshed time.Time
+	maxLevel       int
+	mtx            sync.Mutex
+	logger         log.Logger
+}
+
+func init() {
+	registerCollector("daemon_log", false, NewLSFDaemonLogCollector)
+}
+
+// NewLSFDaemonLogCollector returns a new Collector exposing the number of
+// warning and error lines of the LSF daemon logs.
+func NewLSFDaemonLogCollector(logger log.Logger) (Collector, error) {
+	files := *daemonLogFiles
+	if len(files) == 0 {
+		logdir := os.Getenv("LSF_LOGDIR")
+		if logdir == "" {
+			return nil, fmt.Errorf("LSF_LOGDIR is not set, the daemon log files must be given")
+		}
+		host, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		for _, daemon := range []string{"mbatchd", "mbschd", "lim", "sbatchd", "res"} {
+			files = append(files, filepath.Join(logdir, daemon+".log."+host))
+		}
+	}
+
+	c := &daemonLogCollector{
+		Lines: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "daemon_log", "lines_total"),
+			"The number of lines of the daemon log by level and message template, the numbers, the host names of lshosts and the values in angle brackets of the message are replaced by *.",
+			[]string{"daemon", "level", "template"}, nil,
+		),
+		lines:     map[daemonLogKey]float64{},
+		templates: map[string]map[string]bool{},
+		logger:    logger,
+	}
+	for i, name := range daemonLogLevels {
+		if name == *daemonLogLevel {
+			c.maxLevel = i
+		}
+	}
+	// The lines written before the exporter started are not counted.
+	for _, file := range files {
+		t := &fileTailer{path: file}
+		if err := t.skip(); err != nil && !os.IsNotExist(err) {
+			level.Error(logger).Log("err: ", err)
+		}
+		c.tailers = append(c.tailers, t)
+	}
+
+	return c, nil
+}
+
+// Update calls (*daemonLogCollector).parseDaemonLogs to get the daemon log
+// line counters.
+func (c *daemonLogCollector) Update(ch chan<- prometheus.Metric) error {
+	err := c.parseDaemonLogs(ch)
+	if err != nil {
+		return fmt.Errorf("couldn't get daemon log infomation: %w", err)
+	}
+
+	return nil
+}
+
+// count counts a line of the log of the daemon.
+func (c *daemonLogCollector) count(daemon, line string) {
+	match := DaemonLogLineRegex.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+	lvl, _ := strconv.Atoi(match[DaemonLogLineRegex.SubexpIndex("level")])
+	if lvl > c.maxLevel || lvl >= len(daemonLogLevels) {
+		return
+	}
+
+	template := daemonLogTemplate(match[DaemonLogLineRegex.SubexpIndex("message")], c.hosts)
+	templates, ok := c.templates[daemon]
+	if !ok {
+		templates = map[string]bool{}
+		c.templates[daemon] = templates
+	}
+	if !templates[template] {
+		if len(templates) >= *daemonLogMaxTemplates {
+			template = "other"
+		} else {
+			templates[template] = true
+		}
+	}
+	c.lines[daemonLogKey{daemon, daemonLogLevels[lvl], template}]++
+}
+
+func (c *daemonLogCollector) parseDaemonLogs(ch chan<- prometheus.Metric) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if time.Since(c.hostsRefreshed) > daemonLogHostsRefresh {
+		hosts, err := daemonLogHosts(c.logger)
+		if err != nil {
+			level.Error(c.logger).Log("err: ", err)
+		} else {
+			c.hosts = hosts
+		}
+		c.hostsRefreshed = time.Now()
+	}
+
+	for _, t := range c.tailers {
+		daemon, _, _ := strings.Cut(filepath.Base(t.path), ".log")
+		err := t.read(func(line string) {
+			c.count(daemon, line)
+		})
+		if err != nil && !os.IsNotExist(err) {
+			level.Error(c.logger).Log("err: ", err)
+		}
+	}
+
+	for k, v := range c.lines {
+		ch <- prometheus.MustNewConstMetric(c.Lines, prometheus.CounterValue, v, k.daemon, k.level, k.template)
+	}
+
+	return nil
+}
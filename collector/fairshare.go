@@ -0,0 +1,138 @@
+package collector
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// fairshareMetrics holds the descriptions shared by the queue and the host
+// partition fairshare metrics, so that both can be graphed by the same
+// dashboards. The type label is either queue or host_partition.
+type fairshareMetrics struct {
+	Shares   *prometheus.Desc
+	Priority *prometheus.Desc
+	Started  *prometheus.Desc
+	Reserved *prometheus.Desc
+	CpuTime  *prometheus.Desc
+	RunTime  *prometheus.Desc
+	Adjust   *prometheus.Desc
+}
+
+func newFairshareMetrics() *fairshareMetrics {
+	labels := []string{"type", "name", "path", "account"}
+
+	return &fairshareMetrics{
+		Shares: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "fairshare", "shares"),
+			"The number of shares assigned to the share account.",
+			labels, nil,
+		),
+		Priority: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "fairshare", "priority"),
+			"The dynamic share priority of the share account.",
+			labels, nil,
+		),
+		Started: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "fairshare", "started_count"),
+			"The number of job slots used by running or suspended jobs of the share account.",
+			labels, nil,
+		),
+		Reserved: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "fairshare", "reserved_count"),
+			"The number of job slots reserved by the jobs of the share account.",
+			labels, nil,
+		),
+		CpuTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "fairshare", "cpu_time_seconds"),
+			"The cumulative CPU time used by the share account, decayed by HIST_HOURS.",
+			labels, nil,
+		),
+		RunTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "fairshare", "run_time_seconds"),
+			"The wall-clock run time of the running jobs of the share account.",
+			labels, nil,
+		),
+		Adjust: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "fairshare", "adjust"),
+			"The dynamic priority calculation adjustment of the share account.",
+			labels, nil,
+		),
+	}
+}
+
+// fairshare_ParseOutput parses the SHARE_INFO_FOR tables printed by
+// `bqueues -r` and `bhpart -r`. The first element of the SHARE_INFO_FOR path
+// is the queue or host partition, the rest is the share group path.
+func fairshare_ParseOutput(lsfOutput []byte) []fairshareInfo {
+	var shares []fairshareInfo
+	var header []string
+	var name, path string
+
+	for _, line := range strings.Split(string(lsfOutput), "\n") {
+		trimmed := strings.TrimSpace(line)
+		fields := strings.Fields(trimmed)
+
+		switch {
+		case strings.HasPrefix(trimmed, "SHARE_INFO_FOR:"):
+			infoFor := strings.Trim(strings.TrimSpace(strings.TrimPrefix(trimmed, "SHARE_INFO_FOR:")), "/")
+			parts := strings.SplitN(infoFor, "/", 2)
+			name, path = parts[0], "/"
+			if len(parts) == 2 {
+				path = "/" + parts[1]
+			}
+			header = nil
+		case name == "":
+			continue
+		case len(fields) == 0:
+			if header != nil {
+				name = ""
+			}
+		case fields[0] == "USER/GROUP":
+			header = fields
+		case header == nil:
+			name = ""
+		default:
+			values := map[string]string{}
+			for j, column := range header[1:] {
+				if j+1 < len(fields) {
+					values[column] = fields[j+1]
+				}
+			}
+			shares = append(shares, fairshareInfo{
+				NAME:    name,
+				PATH:    path,
+				ACCOUNT: strings.TrimSuffix(fields[0], "/"),
+				VALUES:  values,
+			})
+		}
+	}
+	return shares
+}
+
+// collect exports the fairshare tables, shareType is either queue or
+// host_partition.
+func (m *fairshareMetrics) collect(ch chan<- prometheus.Metric, shareType string, shares []fairshareInfo) {
+	columns := []struct {
+		name string
+		desc *prometheus.Desc
+	}{
+		{"SHARES", m.Shares},
+		{"PRIORITY", m.Priority},
+		{"STARTED", m.Started},
+		{"RESERVED", m.Reserved},
+		{"CPU_TIME", m.CpuTime},
+		{"RUN_TIME", m.RunTime},
+		{"ADJUST", m.Adjust},
+	}
+
+	for _, share := range shares {
+		for _, column := range columns {
+			value, ok := ConvertLoadValue(share.VALUES[column.name])
+			if !ok {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(column.desc, prometheus.GaugeValue, value, shareType, share.NAME, share.PATH, share.ACCOUNT)
+		}
+	}
+}
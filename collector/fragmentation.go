@@ -0,0 +1,222 @@
+package collector
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	kingpin "github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	fragmentationShapes = kingpin.Flag("collector.fragmentation.shape", "Job shape as name:cores:memory:span, the memory needs a unit (e.g. 512M or 4G) or is 0, the span is host (all cores on one host), cluster (any hosts) or cu:<type> (hosts of one compute unit of that type), e.g. 64c_rack:64:0:cu:rack. Can be repeated.").Default("1c_4g:1:4G:host", "16c:16:0:host", "64c:64:0:cluster").Strings()
+)
+
+// jobShape is a job size whose schedulable count is exported by the
+// fragmentation collector. The memory is in KB and applies to the whole job.
+type jobShape struct {
+	name   string
+	cores  float64
+	memory float64
+	span   string
+	cuType string
+}
+
+// parseJobShape parses a job shape given as name:cores:memory:span.
+func parseJobShape(shape string) (jobShape, error) {
+	parts := strings.SplitN(shape, ":", 4)
+	if len(parts) != 4 {
+		return jobShape{}, fmt.Errorf("invalid job shape %q, expected name:cores:memory:span", shape)
+	}
+
+	cores, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil || cores <= 0 {
+		return jobShape{}, fmt.Errorf("invalid cores of job shape %q", shape)
+	}
+	memory := float64(0)
+	if parts[2] != "0" {
+		// A bare number would be taken as KB, which is rarely meant.
+		if _, err := strconv.ParseFloat(parts[2], 64); err == nil {
+			return jobShape{}, fmt.Errorf("memory of job shape %q needs a unit, e.g. %sG", shape, parts[2])
+		}
+		var ok bool
+		if memory, ok = ConvertLoadValue(parts[2]); !ok {
+			return jobShape{}, fmt.Errorf("invalid memory of job shape %q", shape)
+		}
+	}
+
+	s := jobShape{name: parts[0], cores: cores, memory: memory, span: parts[3]}
+	switch {
+	case s.span == "host" || s.span == "cluster":
+	case strings.HasPrefix(s.span, "cu:"):
+		s.cuType = strings.TrimPrefix(s.span, "cu:")
+		s.span = "cu"
+	default:
+		return jobShape{}, fmt.Errorf("invalid span of job shape %q", shape)
+	}
+	return s, nil
+}
+
+type fragmentationCollector struct {
+	SchedulableJobs      *prometheus.Desc
+	GroupSchedulableJobs *prometheus.Desc
+	LargestFreeSlots     *prometheus.Desc
+	shapes               []jobShape
+	logger               log.Logger
+}
+
+func init() {
+	registerCollector("fragmentation", false, NewLSFFragmentationCollector)
+}
+
+// NewLSFFragmentationCollector returns a new Collector exposing how many jobs
+// of the configured shapes could start on the free slots and memory.
+func NewLSFFragmentationCollector(logger log.Logger) (Collector, error) {
+	var shapes []jobShape
+	names := map[string]bool{}
+	for _, s := range *fragmentationShapes {
+		shape, err := parseJobShape(s)
+		if err != nil {
+			return nil, err
+		}
+		if names[shape.name] {
+			return nil, fmt.Errorf("duplicate job shape name %q", shape.name)
+		}
+		names[shape.name] = true
+		shapes = append(shapes, shape)
+	}
+
+	return &fragmentationCollector{
+		SchedulableJobs: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "fragmentation", "schedulable_jobs"),
+			"The number of jobs of the shape that could start right now on the free slots (bhosts) and free memory (lsload) of the ok hosts.",
+			[]string{"shape"}, nil,
+		),
+		GroupSchedulableJobs: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "fragmentation", "host_group_schedulable_jobs"),
+			"The number of jobs of the shape that could start right now on the hosts of the host group.",
+			[]string{"shape", "group"}, nil,
+		),
+		LargestFreeSlots: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "fragmentation", "largest_free_slots"),
+			"The largest number of free slots on a single ok host.",
+			nil, nil,
+		),
+		shapes: shapes,
+		logger: logger,
+	}, nil
+}
+
+// Update calls (*fragmentationCollector).parseFragmentation to get the
+// schedulable job metrics.
+func (c *fragmentationCollector) Update(ch chan<- prometheus.Metric) error {
+	err := c.parseFragmentation(ch)
+	if err != nil {
+		return fmt.Errorf("couldn't get fragmentation infomation: %w", err)
+	}
+
+	return nil
+}
+
+// usableCores returns the number of cores of a host that jobs of the shape
+// can use, limited by the free slots and the free memory of the host.
+func (s jobShape) usableCores(host *hostCapacity) float64 {
+	if host.STATUS != "ok" || host.MAX <= 0 || host.NJOBS >= host.MAX {
+		return 0
+	}
+	cores := host.MAX - host.NJOBS
+	if s.memory > 0 {
+		if !host.HAS_LOAD {
+			return 0
+		}
+		cores = math.Min(cores, math.Floor(host.MEM/(s.memory/s.cores)))
+	}
+	return cores
+}
+
+// schedulableJobs returns the number of jobs of the shape that fit on hosts.
+func (s jobShape) schedulableJobs(hosts map[string]*hostCapacity, units []computeUnitInfo) float64 {
+	var jobs float64
+
+	switch s.span {
+	case "host":
+		for _, host := range hosts {
+			jobs += math.Floor(s.usableCores(host) / s.cores)
+		}
+	case "cluster":
+		var cores float64
+		for _, host := range hosts {
+			cores += s.usableCores(host)
+		}
+		jobs = math.Floor(cores / s.cores)
+	case "cu":
+		for _, cu := range units {
+			if cu.TYPE != s.cuType {
+				continue
+			}
+			var cores float64
+			for _, name := range cu.HOSTS {
+				if host, ok := hosts[name]; ok {
+					cores += s.usableCores(host)
+				}
+			}
+			jobs += math.Floor(cores / s.cores)
+		}
+	}
+	return jobs
+}
+
+func (c *fragmentationCollector) parseFragmentation(ch chan<- prometheus.Metric) error {
+	hosts, err := hostCapacities(c.logger)
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+
+	allHosts := make([]string, 0, len(hosts))
+	var largest float64
+	for name, host := range hosts {
+		allHosts = append(allHosts, name)
+		if host.STATUS == "ok" && host.MAX-host.NJOBS > largest {
+			largest = host.MAX - host.NJOBS
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(c.LargestFreeSlots, prometheus.GaugeValue, largest)
+
+	var units []computeUnitInfo
+	for _, shape := range c.shapes {
+		if shape.span == "cu" {
+			if units, err = computeUnits(c.logger, allHosts); err != nil {
+				level.Error(c.logger).Log("err: ", err)
+			}
+			break
+		}
+	}
+
+	for _, shape := range c.shapes {
+		ch <- prometheus.MustNewConstMetric(c.SchedulableJobs, prometheus.GaugeValue, shape.schedulableJobs(hosts, units), shape.name)
+	}
+
+	groups, err := hostGroupMembers(c.logger, allHosts)
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+	for group, members := range groups {
+		groupHosts := make(map[string]*hostCapacity, len(members))
+		for _, name := range members {
+			if host, ok := hosts[name]; ok {
+				groupHosts[name] = host
+			}
+		}
+		for _, shape := range c.shapes {
+			ch <- prometheus.MustNewConstMetric(c.GroupSchedulableJobs, prometheus.GaugeValue, shape.schedulableJobs(groupHosts, units), shape.name, group)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package collector
+
+import (
+	"fmt"
+	"math"
+
+	kingpin "github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type loadMismatchCollector struct {
+	SlotRatio     *prometheus.Desc
+	IdleScore     *prometheus.Desc
+	OverloadScore *prometheus.Desc
+	Idle          *prometheus.Desc
+	Overload      *prometheus.Desc
+	logger        log.Logger
+}
+
+var (
+	mismatchIdleSlotRatio     = kingpin.Flag("collector.load_mismatch.idle-slot-ratio", "Minimum ratio of running to configured slots of a host flagged as idle.").Default("0.9").Float64()
+	mismatchIdleUt            = kingpin.Flag("collector.load_mismatch.idle-ut", "Maximum CPU utilization (0 - 1) of a host flagged as idle.").Default("0.1").Float64()
+	mismatchOverloadSlotRatio = kingpin.Flag("collector.load_mismatch.overload-slot-ratio", "Maximum ratio of running to configured slots of a host flagged as overloaded.").Default("0.1").Float64()
+	mismatchOverloadLoad      = kingpin.Flag("collector.load_mismatch.overload-load", "Minimum r1m run queue length per core of a host flagged as overloaded.").Default("1.0").Float64()
+)
+
+func init() {
+	registerCollector("load_mismatch", false, NewLSFLoadMismatchCollector)
+}
+
+// NewLSFLoadMismatchCollector returns a new Collector exposing the hosts whose
+// slot allocation of bhosts and actual load of lsload disagree.
+func NewLSFLoadMismatchCollector(logger log.Logger) (Collector, error) {
+
+	return &loadMismatchCollector{
+		SlotRatio: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "load_mismatch", "slot_ratio"),
+			"The ratio of running to configured slots (RUN/MAX of bhosts) of the host. Hosts without a slot limit use ncpus of lshosts.",
+			[]string{"host_name"}, nil,
+		),
+		IdleScore: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "load_mismatch", "idle_score"),
+			"How much the slot ratio of the host exceeds its CPU utilization, 0 - 1. High values indicate idle or hung jobs.",
+			[]string{"host_name"}, nil,
+		),
+		OverloadScore: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "load_mismatch", "overload_score"),
+			"How much the r1m run queue length per core of the host exceeds its slot ratio. High values indicate processes running outside of LSF.",
+			[]string{"host_name"}, nil,
+		),
+		Idle: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "load_mismatch", "idle"),
+			"1 if the slots of the host are allocated but the host is idle, according to the --collector.load_mismatch.idle-* thresholds.",
+			[]string{"host_name"}, nil,
+		),
+		Overload: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "load_mismatch", "overload"),
+			"1 if the slots of the host are free but the host is loaded, according to the --collector.load_mismatch.overload-* thresholds.",
+			[]string{"host_name"}, nil,
+		),
+		logger: logger,
+	}, nil
+}
+
+// Update calls (*loadMismatchCollector).parseLoadMismatch to get the mismatch
+// metrics.
+func (c *loadMismatchCollector) Update(ch chan<- prometheus.Metric) error {
+	err := c.parseLoadMismatch(ch)
+	if err != nil {
+		return fmt.Errorf("couldn't get load mismatch infomation: %w", err)
+	}
+
+	return nil
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (c *loadMismatchCollector) parseLoadMismatch(ch chan<- prometheus.Metric) error {
+	hosts, err := hostCapacities(c.logger)
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+
+	for _, host := range hosts {
+		if !host.HAS_LOAD || host.NCPUS <= 0 {
+			continue
+		}
+
+		slots := host.MAX
+		if slots <= 0 {
+			slots = host.NCPUS
+		}
+		slotRatio := host.RUN / slots
+		loadRatio := host.R1M / host.NCPUS
+
+		idle := slotRatio >= *mismatchIdleSlotRatio && host.UT <= *mismatchIdleUt
+		overload := slotRatio <= *mismatchOverloadSlotRatio && loadRatio >= *mismatchOverloadLoad
+
+		ch <- prometheus.MustNewConstMetric(c.SlotRatio, prometheus.GaugeValue, slotRatio, host.HOST_NAME)
+		ch <- prometheus.MustNewConstMetric(c.IdleScore, prometheus.GaugeValue, math.Max(0, slotRatio-host.UT), host.HOST_NAME)
+		ch <- prometheus.MustNewConstMetric(c.OverloadScore, prometheus.GaugeValue, math.Max(0, loadRatio-slotRatio), host.HOST_NAME)
+		ch <- prometheus.MustNewConstMetric(c.Idle, prometheus.GaugeValue, boolToFloat(idle), host.HOST_NAME)
+		ch <- prometheus.MustNewConstMetric(c.Overload, prometheus.GaugeValue, boolToFloat(overload), host.HOST_NAME)
+	}
+
+	return nil
+}
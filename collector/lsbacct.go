@@ -0,0 +1,374 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+
+	kingpin "github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	lsbAcctPath      = kingpin.Flag("collector.lsbacct.path", "Path of lsb.acct, defaults to $LSB_SHAREDIR/<cluster>/logdir/lsb.acct.").Default("").String()
+	lsbAcctStateFile = kingpin.Flag("collector.lsbacct.state-file", "File keeping the inode and the byte offset of lsb.acct across restarts, the records written while the exporter was down are read on startup.").Default("").String()
+)
+
+var (
+	lsbAcctTimeBuckets   = []float64{60, 300, 900, 1800, 3600, 7200, 14400, 28800, 86400, 259200, 604800}
+	lsbAcctWaitBuckets   = []float64{10, 30, 60, 300, 900, 1800, 3600, 7200, 14400, 43200, 86400}
+	lsbAcctMemoryBuckets = prometheus.ExponentialBuckets(64*1024, 4, 8)
+)
+
+// parseJobFinish parses a JOB_FINISH record of lsb.acct.
+func parseJobFinish(r lsbRecord) (lsbAcctJob, error) {
+	// jobId userId options numProcessors submitTime beginTime termTime
+	// startTime userName queue resReq dependCond preExecCmd fromHost cwd
+	// inFile outFile errFile jobFile numAskedHosts askedHosts...
+	// termTime is the termination deadline of bsub -t, the job finished at
+	// the event time.
+	job := lsbAcctJob{JOB_ID: r.field(3), USER: r.field(11), QUEUE: r.field(12), RES_REQ: r.field(13)}
+	job.NUM_PROCESSORS, _ = r.float(6)
+	job.SUBMIT_TIME, _ = r.float(7)
+	job.START_TIME, _ = r.float(10)
+	job.END_TIME, _ = r.float(2)
+
+	asked, ok := r.int(22)
+	if !ok {
+		return job, fmt.Errorf("invalid numAskedHosts of job %s", r.field(3))
+	}
+	// numExHosts execHosts... jStatus hostFactor jobName command
+	exHosts := 23 + int(asked)
+	n, ok := r.int(exHosts)
+	if !ok {
+		return job, fmt.Errorf("invalid numExHosts of job %s", r.field(3))
+	}
+	status := exHosts + 1 + int(n)
+	job.STATUS, _ = r.int(status)
+
+	// 19 rusage values starting with utime and stime, mailUser projectName
+	// exitStatus maxNumProcessors loginShell timeEvent idx maxRMem maxRSwap
+	// inFileSpool commandSpool rsvId sla exceptMask additionalInfo exitInfo
+	// warningAction warningTimePeriod chargedSAAP
+	ru := status + 4
+	utime, _ := r.float(ru)
+	stime, _ := r.float(ru + 1)
+	if utime > 0 {
+		job.CPU_TIME += utime
+	}
+	if stime > 0 {
+		job.CPU_TIME += stime
+	}
+	project := ru + 19 + 1
+	job.PROJECT = r.field(project)
+	if job.PROJECT == "" {
+		job.PROJECT = "default"
+	}
+	job.EXIT_STATUS, _ = r.int(project + 1)
+	idx, _ := r.int(project + 5)
+	job.JOB_ID = lsfJobKey(job.JOB_ID, idx)
+	maxRMem := project + 6
+	job.MAX_MEM, ok = r.float(maxRMem)
+	if !ok {
+		return job, fmt.Errorf("short JOB_FINISH record of job %s", r.field(3))
+	}
+	job.CHARGED_SAAP = r.field(maxRMem + 11)
+	return job, nil
+}
+
+// exitCode returns the exit code of a job from the wait status of the job,
+// a job killed by a signal exits with 128 + the signal as in the shell.
+func exitCode(status int64) string {
+	if sig := status & 0x7f; sig != 0 {
+		return strconv.FormatInt(128+sig, 10)
+	}
+	return strconv.FormatInt(status>>8&0xff, 10)
+}
+
+// constHistogram accumulates the observations of a histogram that is exported
+// with prometheus.MustNewConstHistogram.
+type constHistogram struct {
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+func newConstHistogram(buckets []float64) *constHistogram {
+	return &constHistogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *constHistogram) observe(v float64) {
+	i := sort.SearchFloat64s(h.buckets, v)
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *constHistogram) metric(desc *prometheus.Desc, labels ...string) prometheus.Metric {
+	buckets := make(map[float64]uint64, len(h.buckets))
+	var cumulative uint64
+	for i, upper := range h.buckets {
+		cumulative += h.counts[i]
+		buckets[upper] = cumulative
+	}
+	return prometheus.MustNewConstHistogram(desc, h.count, h.sum, buckets, labels...)
+}
+
+// lsbAcctKey are the labels of the accounting histograms.
+type lsbAcctKey struct {
+	queue, user string
+}
+
+type lsbAcctHistograms struct {
+	runTime, waitTime, cpuTime, maxMemory, turnaround *constHistogram
+}
+
+type lsbAcctCollector struct {
+	RunTime        *prometheus.Desc
+	WaitTime       *prometheus.Desc
+	CpuTime        *prometheus.Desc
+	MaxMemory      *prometheus.Desc
+	TurnaroundTime *prometheus.Desc
+	FinishedJobs   *prometheus.Desc
+	ExitStatus     *prometheus.Desc
+	ParseErrors    *prometheus.Desc
+	Chargeback     *chargeback
+	histograms     map[lsbAcctKey]*lsbAcctHistograms
+	finished       map[[3]string]float64
+	exitStatus     map[[3]string]float64
+	parseErrors    float64
+	tailer         *fileTailer
+	// started is set after the end of lsb.acct was found or the state file
+	// was loaded.
+	started bool
+	mtx     sync.Mutex
+	logger  log.Logger
+}
+
+func init() {
+	registerCollector("lsbacct", false, NewLSFLsbAcctCollector)
+}
+
+// NewLSFLsbAcctCollector returns a new Collector exposing histograms and
+// counters of the finished jobs read from lsb.acct.
+func NewLSFLsbAcctCollector(logger log.Logger) (Collector, error) {
+
+	return &lsbAcctCollector{
+		RunTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lsbacct", "run_time_seconds"),
+			"The run time of the finished jobs, from the start to the end of the job.",
+			[]string{"queue", "user"}, nil,
+		),
+		WaitTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lsbacct", "wait_time_seconds"),
+			"The time the finished jobs waited in the queue, from the submission to the start of the job.",
+			[]string{"queue", "user"}, nil,
+		),
+		CpuTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lsbacct", "cpu_time_seconds"),
+			"The user and system CPU time of the finished jobs.",
+			[]string{"queue", "user"}, nil,
+		),
+		MaxMemory: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lsbacct", "max_memory"),
+			"The maximum resident memory of the finished jobs in KB.",
+			[]string{"queue", "user"}, nil,
+		),
+		TurnaroundTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lsbacct", "turnaround_time_seconds"),
+			"The turnaround time of the finished jobs, from the submission to the end of the job.",
+			[]string{"queue", "user"}, nil,
+		),
+		FinishedJobs: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lsbacct", "finished_jobs_total"),
+			"The number of finished jobs by their final status, done or exit.",
+			[]string{"queue", "user", "status"}, nil,
+		),
+		ExitStatus: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lsbacct", "exit_status_total"),
+			"The number of exited jobs by their exit code, 128 + the signal for jobs killed by a signal.",
+			[]string{"queue", "user", "exit_code"}, nil,
+		),
+		ParseErrors: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lsbacct", "parse_errors_total"),
+			"The number of records of lsb.acct that could not be parsed.",
+			nil, nil,
+		),
+		Chargeback: newChargeback(),
+		histograms: map[lsbAcctKey]*lsbAcctHistograms{},
+		finished:   map[[3]string]float64{},
+		exitStatus: map[[3]string]float64{},
+		logger:     logger,
+	}, nil
+}
+
+// Update calls (*lsbAcctCollector).parseLsbAcct to get the accounting
+// metrics.
+func (c *lsbAcctCollector) Update(ch chan<- prometheus.Metric) error {
+	err := c.parseLsbAcct(ch)
+	if err != nil {
+		return fmt.Errorf("couldn't get lsb.acct infomation: %w", err)
+	}
+
+	return nil
+}
+
+// lsbAcctState is the content of the state file.
+type lsbAcctState struct {
+	Inode  uint64 `json:"inode"`
+	Offset int64  `json:"offset"`
+}
+
+func (c *lsbAcctCollector) loadState() {
+	if *lsbAcctStateFile == "" {
+		return
+	}
+	data, err := os.ReadFile(*lsbAcctStateFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			level.Error(c.logger).Log("err: ", err)
+		}
+		return
+	}
+	var state lsbAcctState
+	if err := json.Unmarshal(data, &state); err != nil {
+		level.Error(c.logger).Log("err: ", fmt.Errorf("invalid state file %s: %w", *lsbAcctStateFile, err))
+		return
+	}
+	c.tailer.inode = state.Inode
+	c.tailer.offset = state.Offset
+	c.started = true
+}
+
+func (c *lsbAcctCollector) saveState() error {
+	if *lsbAcctStateFile == "" {
+		return nil
+	}
+	data, err := json.Marshal(lsbAcctState{Inode: c.tailer.inode, Offset: c.tailer.offset})
+	if err != nil {
+		return err
+	}
+	tmp := *lsbAcctStateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, *lsbAcctStateFile)
+}
+
+// process counts a JOB_FINISH record.
+func (c *lsbAcctCollector) process(line string) {
+	if line == "" || line[0] == '#' {
+		return
+	}
+	r, err := parseLsbRecord(line)
+	if err != nil {
+		c.parseErrors++
+		return
+	}
+	if r.field(0) != "JOB_FINISH" {
+		return
+	}
+	job, err := parseJobFinish(r)
+	if err != nil {
+		c.parseErrors++
+		return
+	}
+
+	key := lsbAcctKey{job.QUEUE, job.USER}
+	h, ok := c.histograms[key]
+	if !ok {
+		h = &lsbAcctHistograms{
+			runTime:    newConstHistogram(lsbAcctTimeBuckets),
+			waitTime:   newConstHistogram(lsbAcctWaitBuckets),
+			cpuTime:    newConstHistogram(lsbAcctTimeBuckets),
+			maxMemory:  newConstHistogram(lsbAcctMemoryBuckets),
+			turnaround: newConstHistogram(lsbAcctTimeBuckets),
+		}
+		c.histograms[key] = h
+	}
+	if job.START_TIME > 0 {
+		h.runTime.observe(job.END_TIME - job.START_TIME)
+		h.waitTime.observe(job.START_TIME - job.SUBMIT_TIME)
+		h.cpuTime.observe(job.CPU_TIME)
+		if job.MAX_MEM >= 0 {
+			h.maxMemory.observe(job.MAX_MEM)
+		}
+	}
+	h.turnaround.observe(job.END_TIME - job.SUBMIT_TIME)
+	c.Chargeback.finish(job)
+
+	status := jobStatusName(job.STATUS)
+	c.finished[[3]string{job.QUEUE, job.USER, status}]++
+	if status == "exit" {
+		c.exitStatus[[3]string{job.QUEUE, job.USER, exitCode(job.EXIT_STATUS)}]++
+	}
+}
+
+func (c *lsbAcctCollector) parseLsbAcct(ch chan<- prometheus.Metric) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.tailer == nil {
+		path := *lsbAcctPath
+		if path == "" {
+			var err error
+			if path, err = lsfLogdirFile(c.logger, "lsb.acct"); err != nil {
+				level.Error(c.logger).Log("err: ", err)
+				return nil
+			}
+		}
+		c.tailer = &fileTailer{path: path}
+		c.loadState()
+	}
+
+	// Without a state file the jobs already in lsb.acct at startup are
+	// skipped, the metrics start with the jobs finished after the first read.
+	var err error
+	if !c.started {
+		if err = c.tailer.skip(); err == nil {
+			c.started = true
+		}
+	}
+	if c.started {
+		err = c.tailer.read(c.process)
+	}
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+	} else {
+		if err := c.saveState(); err != nil {
+			level.Error(c.logger).Log("err: ", err)
+		}
+	}
+
+	if *chargebackRunning && c.started {
+		if err := c.Chargeback.accrue(c.logger); err != nil {
+			level.Error(c.logger).Log("err: ", err)
+		}
+	}
+
+	for key, h := range c.histograms {
+		ch <- h.runTime.metric(c.RunTime, key.queue, key.user)
+		ch <- h.waitTime.metric(c.WaitTime, key.queue, key.user)
+		ch <- h.cpuTime.metric(c.CpuTime, key.queue, key.user)
+		ch <- h.maxMemory.metric(c.MaxMemory, key.queue, key.user)
+		ch <- h.turnaround.metric(c.TurnaroundTime, key.queue, key.user)
+	}
+	for key, v := range c.finished {
+		ch <- prometheus.MustNewConstMetric(c.FinishedJobs, prometheus.CounterValue, v, key[0], key[1], key[2])
+	}
+	for key, v := range c.exitStatus {
+		ch <- prometheus.MustNewConstMetric(c.ExitStatus, prometheus.CounterValue, v, key[0], key[1], key[2])
+	}
+	ch <- prometheus.MustNewConstMetric(c.ParseErrors, prometheus.CounterValue, c.parseErrors)
+	c.Chargeback.collect(ch)
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// readJobFinishFixture parses the JOB_FINISH records of fixtures/lsb.acct.
+func readJobFinishFixture(t *testing.T) []lsbAcctJob {
+	t.Helper()
+	data, err := os.ReadFile("fixtures/lsb.acct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var jobs []lsbAcctJob
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		r, err := parseLsbRecord(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		job, err := parseJobFinish(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+func TestParseJobFinish(t *testing.T) {
+	jobs := readJobFinishFixture(t)
+
+	want := []lsbAcctJob{
+		// termTime (bsub -t) is 1700009999, the job finished at the event
+		// time 1700000100.
+		{
+			JOB_ID: "201", QUEUE: "normal", USER: "alice", PROJECT: "projX", RES_REQ: "span[hosts=1]", CHARGED_SAAP: "/groupA/alice",
+			NUM_PROCESSORS: 2, SUBMIT_TIME: 1700000000, START_TIME: 1700000010, END_TIME: 1700000100,
+			CPU_TIME: 35, MAX_MEM: 204800, STATUS: 64,
+		},
+		// An element of a job array with an asked host, killed by SIGKILL.
+		{
+			JOB_ID: "202[3]", QUEUE: "short", USER: "bob", PROJECT: "default", CHARGED_SAAP: "/bob",
+			NUM_PROCESSORS: 1, SUBMIT_TIME: 1700000050, START_TIME: 1700000200, END_TIME: 1700000300,
+			CPU_TIME: 1.5, MAX_MEM: 10240, STATUS: 32, EXIT_STATUS: 9,
+		},
+	}
+	if len(jobs) != len(want) {
+		t.Fatalf("got %d jobs, want %d", len(jobs), len(want))
+	}
+	for i := range want {
+		if jobs[i] != want[i] {
+			t.Errorf("job %d:\ngot  %+v\nwant %+v", i, jobs[i], want[i])
+		}
+	}
+	if got := exitCode(jobs[1].EXIT_STATUS); got != "137" {
+		t.Errorf("exit code of a job killed by SIGKILL: got %s, want 137", got)
+	}
+}
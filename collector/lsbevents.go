@@ -0,0 +1,440 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	kingpin "github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	lsbEventsPath   = kingpin.Flag("collector.lsbevents.path", "Path of lsb.events, defaults to $LSB_SHAREDIR/<cluster>/logdir/lsb.events.").Default("").String()
+	lsbEventsReplay = kingpin.Flag("collector.lsbevents.replay-size", "Size of the end of lsb.events and lsb.stream read at startup to rebuild the queue, user, project and status of the jobs without counting their events.").Default("64MB").Bytes()
+	lsbStreamPath   = kingpin.Flag("collector.lsbstream.path", "Path of the event stream file written with ENABLE_EVENT_STREAM=Y, the EVENT_STREAM_FILE of lsb.params, defaults to $LSB_SHAREDIR/<cluster>/logdir/stream/lsb.stream.").Default("").String()
+)
+
+// lsbRecord is a record of lsb.events or lsb.acct. Every field is a number
+// or a quoted string, the first three fields are the event type, the version
+// and the event time.
+type lsbRecord struct {
+	fields []string
+	quoted []bool
+}
+
+// parseLsbRecord splits a record into its fields, quotes inside a quoted
+// string are doubled.
+func parseLsbRecord(line string) (lsbRecord, error) {
+	var r lsbRecord
+	for i := 0; i < len(line); {
+		switch {
+		case line[i] == ' ' || line[i] == '\t':
+			i++
+		case line[i] == '"':
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(line) {
+					return r, fmt.Errorf("unterminated string in record %q", line)
+				}
+				if line[i] == '"' {
+					if i+1 < len(line) && line[i+1] == '"' {
+						b.WriteByte('"')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				b.WriteByte(line[i])
+				i++
+			}
+			r.fields = append(r.fields, b.String())
+			r.quoted = append(r.quoted, true)
+		default:
+			end := strings.IndexAny(line[i:], " \t")
+			if end < 0 {
+				end = len(line) - i
+			}
+			r.fields = append(r.fields, line[i:i+end])
+			r.quoted = append(r.quoted, false)
+			i += end
+		}
+	}
+	if len(r.fields) < 3 {
+		return r, fmt.Errorf("short record %q", line)
+	}
+	return r, nil
+}
+
+// field returns the i-th field of the record or "" if the record is shorter.
+func (r lsbRecord) field(i int) string {
+	if i < 0 || i >= len(r.fields) {
+		return ""
+	}
+	return r.fields[i]
+}
+
+func (r lsbRecord) int(i int) (int64, bool) {
+	v, err := strconv.ParseInt(r.field(i), 10, 64)
+	return v, err == nil
+}
+
+func (r lsbRecord) float(i int) (float64, bool) {
+	v, err := strconv.ParseFloat(r.field(i), 64)
+	return v, err == nil
+}
+
+// jobNewUser is the index of the userName of a JOB_NEW record.
+const jobNewUser = 14
+
+// jobNewFields returns the index of the queue and the project of a JOB_NEW
+// record. The number of resource limits differs between LSF versions, they
+// end at the first quoted field (hostSpec) after the user name.
+func (r lsbRecord) jobNewFields() (queue int, project int) {
+	// jobId userId options options2 numProcessors submitTime beginTime
+	// termTime sigValue chkpntPeriod restartPid userName rLimits... hostSpec
+	// hostFactor umask queue
+	hostSpec := jobNewUser + 1
+	for hostSpec < len(r.fields) && !r.quoted[hostSpec] {
+		hostSpec++
+	}
+	queue = hostSpec + 3
+
+	// resReq fromHost cwd chkpntDir inFile outFile errFile inFileSpool
+	// commandSpool jobSpoolDir subHomeDir jobFile numAskedHosts askedHosts...
+	asked, ok := r.int(queue + 13)
+	if !ok {
+		return queue, -1
+	}
+	// dependCond timeEvent jobName command nxf xf... mailUser projectName
+	nxf := queue + 14 + int(asked) + 4
+	xf, ok := r.int(nxf)
+	if !ok {
+		return queue, -1
+	}
+	return queue, nxf + 1 + 3*int(xf) + 1
+}
+
+// jobStatusName returns the name of the jStatus bit mask of a job.
+func jobStatusName(status int64) string {
+	switch {
+	case status&0x40 != 0:
+		return "done"
+	case status&0x20 != 0:
+		return "exit"
+	case status&0x10 != 0:
+		return "ususp"
+	case status&0x08 != 0:
+		return "ssusp"
+	case status&0x02 != 0:
+		return "psusp"
+	case status&0x04 != 0:
+		return "run"
+	case status&0x01 != 0:
+		return "pend"
+	case status&0x10000 != 0:
+		return "unkwn"
+	}
+	return "other"
+}
+
+// lsfClusterName returns the cluster name printed by lsid.
+func lsfClusterName(logger log.Logger) (string, error) {
+	output, err := lsfOutput(logger, "lsid")
+	if err != nil {
+		return "", err
+	}
+	matches := ClusterNameRegex.FindStringSubmatch(string(output))
+	if matches == nil {
+		return "", fmt.Errorf("no cluster name in the output of lsid")
+	}
+	return matches[ClusterNameRegex.SubexpIndex("cluster_name")], nil
+}
+
+// lsfLogdirFile returns the path of a file in the logdir of mbatchd,
+// $LSB_SHAREDIR/<cluster>/logdir/<name>.
+func lsfLogdirFile(logger log.Logger, name string) (string, error) {
+	sharedir := os.Getenv("LSB_SHAREDIR")
+	if sharedir == "" {
+		return "", fmt.Errorf("LSB_SHAREDIR is not set, the path of %s must be given", name)
+	}
+	cluster, err := lsfClusterName(logger)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(sharedir, cluster, "logdir", name), nil
+}
+
+// lsbEventKey are the labels of the job event counters.
+type lsbEventKey struct {
+	event, queue, user, project string
+}
+
+// lsbEvents keeps the queue, user, project and status of the jobs seen in the
+// job events and counts the events and the status changes of the jobs.
+type lsbEvents struct {
+	Events       *prometheus.Desc
+	StatusEvents *prometheus.Desc
+	Jobs         *prometheus.Desc
+	JobStatus    *prometheus.Desc
+	ParseErrors  *prometheus.Desc
+	Preemption   *preemptionMetrics
+	jobs         map[string]lsbJob
+	events       map[lsbEventKey]float64
+	status       map[lsbEventKey]float64
+	parseErrors  float64
+}
+
+func newLsbEvents(subsystem, source string) *lsbEvents {
+	return &lsbEvents{
+		Events: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "events_total"),
+			"The number of job events read from "+source+".",
+			[]string{"event", "queue", "user", "project"}, nil,
+		),
+		StatusEvents: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "job_status_total"),
+			"The number of job status changes read from the JOB_STATUS events of "+source+".",
+			[]string{"status", "queue", "user", "project"}, nil,
+		),
+		Jobs: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "tracked_jobs"),
+			"The number of jobs whose queue, user and project are kept until their JOB_CLEAN event. Jobs submitted before the part of the file read at startup are not tracked.",
+			nil, nil,
+		),
+		JobStatus: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "jobs"),
+			"The number of jobs in each status according to the events of "+source+", finished jobs are counted until their JOB_CLEAN event. Jobs submitted before the part of the file read at startup, --collector.lsbevents.replay-size, are not counted.",
+			[]string{"status", "queue"}, nil,
+		),
+		ParseErrors: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "parse_errors_total"),
+			"The number of records of "+source+" that could not be parsed.",
+			nil, nil,
+		),
+		Preemption: newPreemptionMetrics(subsystem, source),
+		jobs:       map[string]lsbJob{},
+		events:     map[lsbEventKey]float64{},
+		status:     map[lsbEventKey]float64{},
+	}
+}
+
+// process updates the job state from a record and counts it if count is set.
+// The records read at startup only build the job state.
+func (e *lsbEvents) process(line string, count bool) {
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+	r, err := parseLsbRecord(line)
+	if err != nil {
+		if count {
+			e.parseErrors++
+		}
+		return
+	}
+
+	event := r.field(0)
+	var jobID string
+	switch event {
+	case "JOB_NEW":
+		jobID = r.field(3)
+		queue, project := r.jobNewFields()
+		job := lsbJob{QUEUE: r.field(queue), USER: r.field(jobNewUser), PROJECT: "default", STATUS: "pend"}
+		if project > 0 && r.field(project) != "" {
+			job.PROJECT = r.field(project)
+		}
+		e.jobs[jobID] = job
+	case "JOB_SWITCH":
+		// userId jobId queue idx userName
+		jobID = r.field(4)
+		if job, ok := e.jobs[jobID]; ok {
+			job.QUEUE = r.field(5)
+			e.jobs[jobID] = job
+		}
+	case "JOB_FINISH":
+		// jobId userId options numProcessors submitTime beginTime termTime
+		// startTime userName queue
+		jobID = r.field(3)
+		if _, ok := e.jobs[jobID]; !ok {
+			e.jobs[jobID] = lsbJob{QUEUE: r.field(12), USER: r.field(11), PROJECT: "default"}
+		}
+	case "JOB_START":
+		jobID = r.field(3)
+		if job, ok := e.jobs[jobID]; ok {
+			job.STATUS = "run"
+			e.Preemption.started(jobID, &job, r, count)
+			e.jobs[jobID] = job
+		}
+	case "JOB_STATUS":
+		jobID = r.field(3)
+		if job, ok := e.jobs[jobID]; ok {
+			if status, ok := r.int(4); ok {
+				previous := job.STATUS
+				job.STATUS = jobStatusName(status)
+				e.Preemption.statusChanged(jobID, &job, r, previous, count)
+				e.jobs[jobID] = job
+			}
+		}
+	case "JOB_SIGNAL":
+		jobID = r.field(3)
+		if job, ok := e.jobs[jobID]; ok {
+			e.Preemption.signaled(jobID, &job, r, count)
+			e.jobs[jobID] = job
+		}
+	case "JOB_REQUEUE":
+		jobID = r.field(3)
+		if job, ok := e.jobs[jobID]; ok {
+			e.Preemption.requeued(&job, count)
+			e.jobs[jobID] = job
+		}
+	case "JOB_CLEAN":
+		// The elements of a job array are cleaned with the array.
+		if idx, _ := r.int(4); idx == 0 {
+			delete(e.jobs, r.field(3))
+		}
+		return
+	default:
+		return
+	}
+
+	if !count {
+		return
+	}
+	job, ok := e.jobs[jobID]
+	if !ok {
+		job = lsbJob{QUEUE: "unknown", USER: "unknown", PROJECT: "unknown"}
+	}
+	e.events[lsbEventKey{event, job.QUEUE, job.USER, job.PROJECT}]++
+	if event == "JOB_STATUS" {
+		if status, ok := r.int(4); ok {
+			e.status[lsbEventKey{jobStatusName(status), job.QUEUE, job.USER, job.PROJECT}]++
+		}
+	}
+}
+
+func (e *lsbEvents) collect(ch chan<- prometheus.Metric) {
+	for k, v := range e.events {
+		ch <- prometheus.MustNewConstMetric(e.Events, prometheus.CounterValue, v, k.event, k.queue, k.user, k.project)
+	}
+	for k, v := range e.status {
+		ch <- prometheus.MustNewConstMetric(e.StatusEvents, prometheus.CounterValue, v, k.event, k.queue, k.user, k.project)
+	}
+	ch <- prometheus.MustNewConstMetric(e.Jobs, prometheus.GaugeValue, float64(len(e.jobs)))
+
+	jobs := map[[2]string]float64{}
+	for _, job := range e.jobs {
+		if job.STATUS != "" {
+			jobs[[2]string{job.STATUS, job.QUEUE}]++
+		}
+	}
+	for k, v := range jobs {
+		ch <- prometheus.MustNewConstMetric(e.JobStatus, prometheus.GaugeValue, v, k[0], k[1])
+	}
+	ch <- prometheus.MustNewConstMetric(e.ParseErrors, prometheus.CounterValue, e.parseErrors)
+	e.Preemption.collect(ch)
+}
+
+// lsbEventsCollector tails lsb.events or the event stream file lsb.stream,
+// both are written in the same record format.
+type lsbEventsCollector struct {
+	events *lsbEvents
+	tailer *fileTailer
+	// path is the flag of the file, file its default path in the logdir.
+	path *string
+	file string
+	// started is set once the end of the file at startup was read.
+	started bool
+	mtx     sync.Mutex
+	logger  log.Logger
+}
+
+func init() {
+	registerCollector("lsbevents", false, NewLSFLsbEventsCollector)
+	registerCollector("lsbstream", false, NewLSFLsbStreamCollector)
+}
+
+// NewLSFLsbEventsCollector returns a new Collector exposing counters of the
+// job events tailed from lsb.events.
+func NewLSFLsbEventsCollector(logger log.Logger) (Collector, error) {
+
+	return &lsbEventsCollector{
+		events: newLsbEvents("lsbevents", "lsb.events"),
+		path:   lsbEventsPath,
+		file:   "lsb.events",
+		logger: logger,
+	}, nil
+}
+
+// NewLSFLsbStreamCollector returns a new Collector exposing counters of the
+// job events tailed from the event stream file lsb.stream.
+func NewLSFLsbStreamCollector(logger log.Logger) (Collector, error) {
+
+	return &lsbEventsCollector{
+		events: newLsbEvents("lsbstream", "lsb.stream"),
+		path:   lsbStreamPath,
+		file:   filepath.Join("stream", "lsb.stream"),
+		logger: logger,
+	}, nil
+}
+
+// Update calls (*lsbEventsCollector).parseLsbEvents to get the job event
+// counters.
+func (c *lsbEventsCollector) Update(ch chan<- prometheus.Metric) error {
+	err := c.parseLsbEvents(ch)
+	if err != nil {
+		return fmt.Errorf("couldn't get %s infomation: %w", filepath.Base(c.file), err)
+	}
+
+	return nil
+}
+
+func (c *lsbEventsCollector) parseLsbEvents(ch chan<- prometheus.Metric) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.tailer == nil {
+		path := *c.path
+		if path == "" {
+			var err error
+			if path, err = lsfLogdirFile(c.logger, c.file); err != nil {
+				level.Error(c.logger).Log("err: ", err)
+				return nil
+			}
+		}
+		c.tailer = &fileTailer{path: path}
+	}
+
+	// The end of the file at startup only builds the job state, the counters
+	// start with the events written after it. Reading all of a large
+	// lsb.events would block the scrape, the events of the jobs submitted
+	// before the replayed part are counted with the unknown queue, user and
+	// project.
+	if !c.started {
+		err := c.tailer.readTail(int64(*lsbEventsReplay), func(line string) {
+			c.events.process(line, false)
+		})
+		if err != nil {
+			level.Error(c.logger).Log("err: ", err)
+			c.events.collect(ch)
+			return nil
+		}
+		c.started = true
+	}
+
+	err := c.tailer.read(func(line string) {
+		c.events.process(line, true)
+	})
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+	}
+	c.events.collect(ch)
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// processFixture runs the records of fixtures/lsb.events up to and including
+// the n-th record through a new lsbEvents.
+func processFixture(t *testing.T, n int) *lsbEvents {
+	t.Helper()
+	data, err := os.ReadFile("fixtures/lsb.events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := newLsbEvents("lsbevents", "lsb.events")
+	records := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		if records == n {
+			break
+		}
+		if strings.HasPrefix(line, "\"") {
+			records++
+		}
+		e.process(line, true)
+	}
+	return e
+}
+
+func TestLsbEventsJobNew(t *testing.T) {
+	e := processFixture(t, 2)
+
+	want := map[string]lsbJob{
+		"101": {QUEUE: "normal", USER: "alice", PROJECT: "projX", STATUS: "pend"},
+		// 11 resource limits, asked hosts and a transferred file.
+		"102": {QUEUE: "short", USER: "bob", PROJECT: "projY", STATUS: "pend"},
+	}
+	for id, job := range want {
+		if got := e.jobs[id]; got != job {
+			t.Errorf("job %s: got %+v, want %+v", id, got, job)
+		}
+	}
+	if got := e.events[lsbEventKey{"JOB_NEW", "normal", "alice", "projX"}]; got != 1 {
+		t.Errorf("JOB_NEW events of job 101: got %v, want 1", got)
+	}
+	if e.parseErrors != 0 {
+		t.Errorf("parse errors: got %v, want 0", e.parseErrors)
+	}
+}
+
+func TestLsbEventsJobStart(t *testing.T) {
+	e := processFixture(t, 3)
+
+	job := e.jobs["101"]
+	if job.STATUS != "run" || job.HOST != "hostA" || job.START_TIME != 1700000100 {
+		t.Errorf("job 101: got %+v, want run on hostA since 1700000100", job)
+	}
+	if got := e.events[lsbEventKey{"JOB_START", "normal", "alice", "projX"}]; got != 1 {
+		t.Errorf("JOB_START events of job 101: got %v, want 1", got)
+	}
+}
+
+func TestLsbEventsJobStatus(t *testing.T) {
+	e := processFixture(t, 6)
+
+	if got := e.jobs["101"].STATUS; got != "done" {
+		t.Errorf("status of job 101: got %q, want done", got)
+	}
+	for status, want := range map[string]float64{"ssusp": 1, "run": 1, "done": 1} {
+		if got := e.status[lsbEventKey{status, "normal", "alice", "projX"}]; got != want {
+			t.Errorf("%s status changes of job 101: got %v, want %v", status, got, want)
+		}
+	}
+	// Suspended by mbatchd preemption at 1700000200, resumed at 1700000500.
+	if got := e.Preemption.lost["normal"]; got != 300 {
+		t.Errorf("preemption lost time: got %v, want 300", got)
+	}
+}
+
+func TestLsbEventsReplay(t *testing.T) {
+	data, err := os.ReadFile("fixtures/lsb.events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	path := filepath.Join(t.TempDir(), "lsb.events")
+	// The file at startup holds the JOB_NEW records of the jobs 101 and 102,
+	// the replayed part starts in the middle of the first one.
+	if err := os.WriteFile(path, []byte(lines[0]+lines[1]+lines[2]), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := newLsbEvents("lsbevents", "lsb.events")
+	tailer := &fileTailer{path: path}
+	if err := tailer.readTail(int64(len(lines[2])+10), func(line string) { e.process(line, false) }); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := e.jobs["101"]; ok {
+		t.Errorf("job 101 of the cut line is tracked")
+	}
+	if got := e.jobs["102"].QUEUE; got != "short" {
+		t.Errorf("queue of the replayed job 102: got %q, want short", got)
+	}
+	if len(e.events) != 0 || e.parseErrors != 0 {
+		t.Errorf("replayed records are counted: %v events, %v parse errors", e.events, e.parseErrors)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(lines[3]); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if err := tailer.read(func(line string) { e.process(line, true) }); err != nil {
+		t.Fatal(err)
+	}
+	// Job 101 was submitted before the replayed part.
+	if got := e.events[lsbEventKey{"JOB_START", "unknown", "unknown", "unknown"}]; got != 1 || len(e.events) != 1 {
+		t.Errorf("events after the replay: got %v, want the JOB_START of job 101 with the unknown labels", e.events)
+	}
+}
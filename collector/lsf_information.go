@@ -1,9 +1,11 @@
 package collector
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -67,10 +69,38 @@ func lsfOutput(logger log.Logger, exe_file string, args ...string) ([]byte, erro
 	// 	os.Exit(1)
 	// }
 
-	cmd := exec.Command(exe_file, args...)
+	return lsfOutputContext(context.Background(), logger, exe_file, args...)
+}
+
+// lsfCommandName returns the command label of the duration of an LSF command,
+// the subcommand is kept for badmin.
+func lsfCommandName(exe_file string, args []string) string {
+	if exe_file == "badmin" && len(args) > 0 {
+		return exe_file + " " + args[0]
+	}
+	return exe_file
+}
 
-	out, err := cmd.Output()
+// runLsfCommand runs an LSF command with run, e.g. (*exec.Cmd).Output. The
+// command is killed when ctx is done and its duration is observed in
+// lsfCommandDuration.
+func runLsfCommand(ctx context.Context, run func(*exec.Cmd) ([]byte, error), exe_file string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, exe_file, args...)
+	// Do not wait for the children of a killed command that keep its output
+	// open.
+	cmd.WaitDelay = time.Second
+
+	begin := time.Now()
+	out, err := run(cmd)
+	lsfCommandDuration.WithLabelValues(lsfCommandName(exe_file, args)).Observe(time.Since(begin).Seconds())
+	return out, err
+}
 
+// lsfOutputContext runs an LSF command like lsfOutput, the command is killed
+// when ctx is done. The duration of the command is observed in
+// lsfCommandDuration.
+func lsfOutputContext(ctx context.Context, logger log.Logger, exe_file string, args ...string) ([]byte, error) {
+	out, err := runLsfCommand(ctx, (*exec.Cmd).Output, exe_file, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error while calling '%s %s': %v:'unknown error'",
 			exe_file, strings.Join(args, " "), err)
@@ -116,3 +146,16 @@ func (c *InformationCollector) parsebLsfClusterInfo(ch chan<- prometheus.Metric)
 
 	return nil
 }
+
+// lsfCombinedOutput runs an LSF command like lsfOutputContext, but returns its
+// standard output and standard error also when the command fails, so that
+// the message of LSF can be inspected.
+func lsfCombinedOutput(ctx context.Context, logger log.Logger, exe_file string, args ...string) ([]byte, error) {
+	out, err := runLsfCommand(ctx, (*exec.Cmd).CombinedOutput, exe_file, args...)
+	if err != nil {
+		return out, fmt.Errorf("error while calling '%s %s': %v:'%s'",
+			exe_file, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+
+	return out, nil
+}
@@ -1,10 +1,16 @@
 package collector
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
+	kingpin "github.com/alecthomas/kingpin/v2"
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -21,7 +27,26 @@ type Job struct {
 
 type JobCollector struct {
 	JobInfo *prometheus.Desc
-	logger  log.Logger
+
+	PendingJobs     *prometheus.Desc
+	PendingSlots    *prometheus.Desc
+	PendingMemory   *prometheus.Desc
+	PendingGpus     *prometheus.Desc
+	QueueFreeSlots  *prometheus.Desc
+	QueueFreeMemory *prometheus.Desc
+	QueueFreeGpus   *prometheus.Desc
+	logger          log.Logger
+}
+
+var (
+	jobRusageUnit    = kingpin.Flag("collector.lsfjob.rusage-unit", "Unit of the mem value of rusage, the LSF_UNIT_FOR_LIMITS parameter of lsf.conf (K, M, G, T).").Default("M").String()
+	jobRusagePerTask = kingpin.Flag("collector.lsfjob.rusage-per-task", "Multiply the rusage of a pending job by its requested slots, set it when RESOURCE_RESERVE_PER_TASK=Y in lsb.params.").Default("false").Bool()
+	jobPendingDemand = kingpin.Flag("collector.lsfjob.pending-demand", "Also export the slots, memory and GPUs requested by the pending jobs of `bjobs -p` next to the free capacity of the hosts of every queue.").Default("false").Bool()
+)
+
+// bjobsJSON is the output of `bjobs -o ... -json`.
+type bjobsJSON struct {
+	RECORDS []map[string]string `json:"RECORDS"`
 }
 
 func init() {
@@ -37,6 +62,41 @@ func NewLSFJobCollector(logger log.Logger) (Collector, error) {
 			"bjobs status labeled by id, user, status, queue and FromHost of the starttime.",
 			[]string{"ID", "User", "Status", "Queue", "FromHost", "ExecutionHost", "JobName"}, nil,
 		),
+		PendingJobs: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bjobs", "pending_jobs"),
+			"The number of pending jobs in the queue.",
+			[]string{"queues_name"}, nil,
+		),
+		PendingSlots: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bjobs", "pending_slots"),
+			"The number of slots requested by the pending jobs in the queue.",
+			[]string{"queues_name"}, nil,
+		),
+		PendingMemory: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bjobs", "pending_memory"),
+			"The memory in KB requested by the rusage of the pending jobs in the queue.",
+			[]string{"queues_name"}, nil,
+		),
+		PendingGpus: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bjobs", "pending_gpus"),
+			"The number of GPUs requested by the pending jobs in the queue.",
+			[]string{"queues_name"}, nil,
+		),
+		QueueFreeSlots: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bjobs", "queue_free_slots"),
+			"The number of free slots on the ok hosts eligible for the queue.",
+			[]string{"queues_name"}, nil,
+		),
+		QueueFreeMemory: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bjobs", "queue_free_memory"),
+			"The free memory in KB (mem of lsload) on the ok hosts eligible for the queue.",
+			[]string{"queues_name"}, nil,
+		),
+		QueueFreeGpus: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bjobs", "queue_free_gpus"),
+			"The number of GPUs that are not allocated on the ok hosts eligible for the queue.",
+			[]string{"queues_name"}, nil,
+		),
 		logger: logger,
 	}, nil
 }
@@ -54,6 +114,13 @@ func (c *JobCollector) Update(ch chan<- prometheus.Metric) error {
 		return fmt.Errorf("couldn't get queues infomation: %w", err)
 	}
 
+	if *jobPendingDemand {
+		err = c.getPendingDemand(ch)
+		if err != nil {
+			return fmt.Errorf("couldn't get pending demand infomation: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -78,3 +145,147 @@ func (c *JobCollector) getJobStatus(ch chan<- prometheus.Metric) error {
 	return nil
 
 }
+
+// parseRusage returns the values of the first rusage section of a resource
+// requirement, e.g. rusage[mem=4096.00:ngpus_physical=1.00].
+func parseRusage(resreq string) map[string]string {
+	rusage := map[string]string{}
+
+	start := strings.Index(resreq, "rusage[")
+	if start < 0 {
+		return rusage
+	}
+	section := resreq[start+len("rusage["):]
+	if end := strings.Index(section, "]"); end >= 0 {
+		section = section[:end]
+	}
+	// Alternative rusage sections are separated by ||, only the first is used.
+	section, _, _ = strings.Cut(section, "||")
+	for _, item := range strings.FieldsFunc(section, func(r rune) bool { return r == ':' || r == ',' }) {
+		if name, value, found := strings.Cut(item, "="); found {
+			rusage[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	return rusage
+}
+
+// rusageGpus returns the number of GPUs requested by a job from the GPU_NUM
+// field of bjobs or from its rusage.
+func rusageGpus(gpuNum string, rusage map[string]string) float64 {
+	if gpus, err := strconv.ParseFloat(gpuNum, 64); err == nil {
+		return gpus
+	}
+	for _, name := range []string{"ngpus_physical", "ngpus", "ngpus_shared", "ngpus_excl_t", "ngpus_excl_p"} {
+		if gpus, err := strconv.ParseFloat(rusage[name], 64); err == nil {
+			return gpus
+		}
+	}
+	return 0
+}
+
+func (c *JobCollector) getPendingDemand(ch chan<- prometheus.Metric) error {
+	output, err := lsfOutput(c.logger, "bjobs", "-u", "all", "-p", "-o", "jobid queue nreq_slot combined_resreq gpu_num", "-json")
+	if err != nil {
+		level.Error(c.logger).Log("msg", "couldn't get the pending jobs", "err", err)
+		return nil
+	}
+	var jobs bjobsJSON
+	if err := json.Unmarshal(output, &jobs); err != nil {
+		level.Error(c.logger).Log("msg", "couldn't parse the pending jobs", "err", err)
+		return nil
+	}
+
+	type demand struct {
+		jobs, slots, memory, gpus float64
+	}
+	queues := map[string]*demand{}
+	for _, job := range jobs.RECORDS {
+		queue := job["QUEUE"]
+		if queue == "" {
+			continue
+		}
+		d, ok := queues[queue]
+		if !ok {
+			d = &demand{}
+			queues[queue] = d
+		}
+
+		slots, err := strconv.ParseFloat(job["NREQ_SLOT"], 64)
+		if err != nil {
+			slots = 1
+		}
+		rusage := parseRusage(job["COMBINED_RESREQ"])
+		memory, _ := strconv.ParseFloat(rusage["mem"], 64)
+		memory = math.Max(0, FormatlshostsUnit(memory, strings.ToUpper(*jobRusageUnit)))
+		gpus := rusageGpus(job["GPU_NUM"], rusage)
+		if *jobRusagePerTask {
+			memory *= slots
+		}
+
+		d.jobs++
+		d.slots += slots
+		d.memory += memory
+		d.gpus += gpus
+	}
+
+	for queue, d := range queues {
+		ch <- prometheus.MustNewConstMetric(c.PendingJobs, prometheus.GaugeValue, d.jobs, queue)
+		ch <- prometheus.MustNewConstMetric(c.PendingSlots, prometheus.GaugeValue, d.slots, queue)
+		ch <- prometheus.MustNewConstMetric(c.PendingMemory, prometheus.GaugeValue, d.memory, queue)
+		ch <- prometheus.MustNewConstMetric(c.PendingGpus, prometheus.GaugeValue, d.gpus, queue)
+	}
+
+	// The capacities are shared with the queue_capacity collector.
+	capacities, err := cachedQueueCapacities(c.logger)
+	if err != nil {
+		level.Error(c.logger).Log("msg", "couldn't get the queue capacities", "err", err)
+		return nil
+	}
+	for queue, capacity := range capacities {
+		ch <- prometheus.MustNewConstMetric(c.QueueFreeSlots, prometheus.GaugeValue, capacity.FREE, queue)
+		ch <- prometheus.MustNewConstMetric(c.QueueFreeMemory, prometheus.GaugeValue, capacity.FREE_MEMORY, queue)
+	}
+
+	gpus, err := hostFreeGpus(c.logger)
+	if err != nil {
+		level.Error(c.logger).Log("msg", "couldn't get the free GPUs of the hosts", "err", err)
+		return nil
+	}
+	for queue, capacity := range capacities {
+		var free float64
+		for _, host := range capacity.HOSTS {
+			free += gpus[host]
+		}
+		ch <- prometheus.MustNewConstMetric(c.QueueFreeGpus, prometheus.GaugeValue, free, queue)
+	}
+
+	return nil
+}
+
+// hostFreeGpus returns the GPUs that are not allocated on the ok hosts of
+// `bhosts -o`.
+func hostFreeGpus(logger log.Logger) (map[string]float64, error) {
+	output, err := lsfOutput(logger, "bhosts", "-o", "host_name status ngpus ngpus_alloc", "-json")
+	if err != nil {
+		return nil, err
+	}
+	var hosts bjobsJSON
+	if err := json.Unmarshal(output, &hosts); err != nil {
+		return nil, err
+	}
+
+	gpus := make(map[string]float64, len(hosts.RECORDS))
+	for _, host := range hosts.RECORDS {
+		if host["STATUS"] != "ok" {
+			continue
+		}
+		// Hosts without GPUs print a dash.
+		total, err := strconv.ParseFloat(host["NGPUS"], 64)
+		if err != nil {
+			continue
+		}
+		alloc, _ := strconv.ParseFloat(host["NGPUS_ALLOC"], 64)
+		gpus[host["HOST_NAME"]] = math.Max(0, total-alloc)
+	}
+	return gpus, nil
+}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 
+	kingpin "github.com/alecthomas/kingpin/v2"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/jszwec/csvutil"
@@ -19,7 +20,26 @@ type lshostsCollector struct {
 	HostMaxSWP *prometheus.Desc
 	HostNCpus  *prometheus.Desc
 	HostCpuf   *prometheus.Desc
-	logger     log.Logger
+
+	HostResource        *prometheus.Desc
+	HostNumericResource *prometheus.Desc
+	HostSockets         *prometheus.Desc
+	HostCores           *prometheus.Desc
+	HostThreads         *prometheus.Desc
+	HostNumaMemory      *prometheus.Desc
+	logger              log.Logger
+}
+
+var (
+	lshostsResources = kingpin.Flag("collector.lshosts.resources", "Also parse `lshosts -l` for the boolean and numeric static resources of the hosts.").Default("false").Bool()
+	lshostsTopology  = kingpin.Flag("collector.lshosts.topology", "Also parse `lshosts -T` for the socket, core, thread and NUMA topology of the hosts.").Default("false").Bool()
+)
+
+// lshostsColumns are the columns of `lshosts -l` that are already exported
+// from `lshosts -w` or that are not numeric.
+var lshostsColumns = map[string]bool{
+	"type": true, "model": true, "cpuf": true, "ncpus": true,
+	"maxmem": true, "maxswp": true, "server": true,
 }
 
 func init() {
@@ -28,27 +48,63 @@ func init() {
 
 // NewLmstatCollector returns a new Collector exposing lmstat license stats.
 func NewLSFlshostCollector(logger log.Logger) (Collector, error) {
+	labels := []string{"host_name", "host_type", "host_model", "server_type"}
+	// The resources of lshosts -w stay a label until lsf_host_resource of
+	// --collector.lshosts.resources replaces them.
+	if !*lshostsResources {
+		labels = append(labels, "resource_type")
+	}
 
 	return &lshostsCollector{
 		HostMaxMem: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "lshosts", "max_mem"),
 			"The maximum amount of physical memory available for user processes.     By default, the amount is displayed in KB. The amount can appear in MB depending on the actual system memory. Use the LSF_UNIT_FOR_LIMITS parameter in the lsf.conf file to specify a larger unit for the limit (GB, TB, PB, or EB).",
-			[]string{"host_name", "host_type", "host_model", "server_type", "resource_type"}, nil,
+			labels, nil,
 		),
 		HostMaxSWP: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "lshosts", "max_swp"),
 			"The total available swap space.  By default, the amount is displayed in KB. The amount can appear in MB depending on the actual system swap space. Use the LSF_UNIT_FOR_LIMITS parameter in the lsf.conf file to specify a larger unit for the limit (GB, TB, PB, or EB).",
-			[]string{"host_name", "host_type", "host_model", "server_type", "resource_type"}, nil,
+			labels, nil,
 		),
 		HostNCpus: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "lshosts", "ncpus"),
 			"The number of processors on this host. If the LSF_ENABLE_DUALCORE=Y parameter is specified in the lsf.conf file for multi-core CPU hosts, displays the number of cores instead of physical CPUs.",
-			[]string{"host_name", "host_type", "host_model", "server_type", "resource_type"}, nil,
+			labels, nil,
 		),
 		HostCpuf: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "lshosts", "cpuf"),
 			"The relative CPU performance factor. The CPU factor is used to scale the CPU load value so that differences in CPU speeds are considered. The faster the CPU, the larger the CPU factor.The default CPU factor of a host with an host type is 1.0. unknown",
-			[]string{"host_name", "host_type", "host_model", "server_type", "resource_type"}, nil,
+			labels, nil,
+		),
+		HostResource: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "host", "resource"),
+			"A metric with a constant '1' value for every boolean resource of the host from lshosts -l.",
+			[]string{"host_name", "resource"}, nil,
+		),
+		HostNumericResource: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "host", "numeric_resource"),
+			"The value of the static numeric resources of the host from lshosts -l, e.g. ndisks, maxtmp, nprocs, ncores, nthreads or site defined resources. Values with a unit are converted to KB.",
+			[]string{"host_name", "resource"}, nil,
+		),
+		HostSockets: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lshosts", "sockets"),
+			"The number of sockets of the host from lshosts -T.",
+			[]string{"host_name"}, nil,
+		),
+		HostCores: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lshosts", "cores"),
+			"The number of cores of the host from lshosts -T.",
+			[]string{"host_name"}, nil,
+		),
+		HostThreads: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lshosts", "threads"),
+			"The number of threads of the host from lshosts -T.",
+			[]string{"host_name"}, nil,
+		),
+		HostNumaMemory: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lshosts", "numa_memory"),
+			"The memory of every NUMA node of the host from lshosts -T in KB.",
+			[]string{"host_name", "numa_node"}, nil,
 		),
 		logger: logger,
 	}, nil
@@ -68,6 +124,20 @@ func (c *lshostsCollector) Update(ch chan<- prometheus.Metric) error {
 		return fmt.Errorf("couldn't get bhosts infomation: %w", err)
 	}
 
+	if *lshostsResources {
+		err = c.parselshostsResources(ch)
+		if err != nil {
+			return fmt.Errorf("couldn't get lshosts -l infomation: %w", err)
+		}
+	}
+
+	if *lshostsTopology {
+		err = c.parselshostsTopology(ch)
+		if err != nil {
+			return fmt.Errorf("couldn't get lshosts -T infomation: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -165,16 +235,159 @@ func (c *lshostsCollector) parselshostsCount(ch chan<- prometheus.Metric) error
 			Cpuf = -1
 		}
 
+		labels := []string{lshost.HOST_NAME, lshost.HOST_TYPE, lshost.Model, ConvertServerType(lshost.Server)}
+		if !*lshostsResources {
+			labels = append(labels, ConvertresourceType(lshost.RESOURCES))
+		}
+
 		var dataSize float64
 		var dataUnit string
 		fmt.Sscanf(lshost.Maxmem, "%f%s", &dataSize, &dataUnit)
-		ch <- prometheus.MustNewConstMetric(c.HostMaxMem, prometheus.GaugeValue, FormatlshostsUnit(dataSize, dataUnit), lshost.HOST_NAME, lshost.HOST_TYPE, lshost.Model, ConvertServerType(lshost.Server), ConvertresourceType(lshost.RESOURCES))
+		ch <- prometheus.MustNewConstMetric(c.HostMaxMem, prometheus.GaugeValue, FormatlshostsUnit(dataSize, dataUnit), labels...)
 
 		fmt.Sscanf(lshost.Maxswp, "%f%s", &dataSize, &dataUnit)
-		ch <- prometheus.MustNewConstMetric(c.HostMaxSWP, prometheus.GaugeValue, FormatlshostsUnit(dataSize, dataUnit), lshost.HOST_NAME, lshost.HOST_TYPE, lshost.Model, ConvertServerType(lshost.Server), ConvertresourceType(lshost.RESOURCES))
+		ch <- prometheus.MustNewConstMetric(c.HostMaxSWP, prometheus.GaugeValue, FormatlshostsUnit(dataSize, dataUnit), labels...)
+
+		ch <- prometheus.MustNewConstMetric(c.HostNCpus, prometheus.GaugeValue, Ncpus, labels...)
+		ch <- prometheus.MustNewConstMetric(c.HostCpuf, prometheus.GaugeValue, Cpuf, labels...)
+	}
+
+	return nil
+}
+
+// lshostsLong_ParseOutput parses the output of `lshosts -l`.
+func lshostsLong_ParseOutput(lsfOutput []byte) []lshostsLongInfo {
+	var lshosts []lshostsLongInfo
+	cur := -1
 
-		ch <- prometheus.MustNewConstMetric(c.HostNCpus, prometheus.GaugeValue, Ncpus, lshost.HOST_NAME, lshost.HOST_TYPE, lshost.Model, ConvertServerType(lshost.Server), ConvertresourceType(lshost.RESOURCES))
-		ch <- prometheus.MustNewConstMetric(c.HostCpuf, prometheus.GaugeValue, Cpuf, lshost.HOST_NAME, lshost.HOST_TYPE, lshost.Model, ConvertServerType(lshost.Server), ConvertresourceType(lshost.RESOURCES))
+	lines := strings.Split(string(lsfOutput), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case strings.HasPrefix(line, "HOST_NAME:"):
+			lshosts = append(lshosts, lshostsLongInfo{
+				HOST_NAME: strings.TrimSpace(strings.TrimPrefix(line, "HOST_NAME:")),
+				STATIC:    map[string]string{},
+			})
+			cur = len(lshosts) - 1
+		case cur < 0:
+			continue
+		case fields[0] == "type" && i+1 < len(lines):
+			values := strings.Fields(lines[i+1])
+			for j, column := range fields {
+				if j < len(values) {
+					lshosts[cur].STATIC[column] = values[j]
+				}
+			}
+			i++
+		case strings.HasPrefix(line, "RESOURCES:"):
+			resources := strings.TrimSpace(ConvertresourceType(strings.TrimPrefix(line, "RESOURCES:")))
+			// A host without resources prints RESOURCES: Not defined.
+			if resources == "-" || strings.EqualFold(resources, "Not defined") {
+				continue
+			}
+			lshosts[cur].RESOURCES = strings.Fields(resources)
+		}
+	}
+	return lshosts
+}
+
+// lshostsTopology_ParseOutput parses the output of `lshosts -T`, hosts with the
+// same topology are listed on the same Host line.
+func lshostsTopology_ParseOutput(lsfOutput []byte) []lshostsTopologyInfo {
+	var topologies []lshostsTopologyInfo
+	cur := -1
+
+	for _, line := range strings.Split(string(lsfOutput), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Host["):
+			// Host[15.7G] hostA hostB
+			end := strings.Index(line, "]")
+			if end < 0 {
+				continue
+			}
+			topologies = append(topologies, lshostsTopologyInfo{
+				HOST_NAMES: strings.Fields(line[end+1:]),
+				NUMA:       map[string]string{},
+			})
+			cur = len(topologies) - 1
+		case cur < 0:
+			continue
+		case strings.HasPrefix(line, "NUMA["):
+			// NUMA[0: 7.7G]
+			node, memory, _ := strings.Cut(strings.Trim(line[len("NUMA["):], "]"), ":")
+			topologies[cur].NUMA[strings.TrimSpace(node)] = strings.TrimSpace(memory)
+		case strings.HasPrefix(line, "Socket"):
+			topologies[cur].SOCKETS++
+		case strings.HasPrefix(line, "core"):
+			// core0(0 16)
+			topologies[cur].CORES++
+			start, end := strings.Index(line, "("), strings.Index(line, ")")
+			if start < 0 || end < start || len(strings.Fields(line[start+1:end])) == 0 {
+				topologies[cur].THREADS++
+			} else {
+				topologies[cur].THREADS += float64(len(strings.Fields(line[start+1 : end])))
+			}
+		}
+	}
+	return topologies
+}
+
+func (c *lshostsCollector) parselshostsResources(ch chan<- prometheus.Metric) error {
+	output, err := lsfOutput(c.logger, "lshosts", "-l")
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+
+	for _, lshost := range lshostsLong_ParseOutput(output) {
+		for column, v := range lshost.STATIC {
+			if lshostsColumns[column] {
+				continue
+			}
+			if value, ok := ConvertLoadValue(v); ok {
+				ch <- prometheus.MustNewConstMetric(c.HostNumericResource, prometheus.GaugeValue, value, lshost.HOST_NAME, column)
+			}
+		}
+
+		for _, resource := range lshost.RESOURCES {
+			// Numeric resources are printed as name=value.
+			name, v, found := strings.Cut(resource, "=")
+			if !found {
+				ch <- prometheus.MustNewConstMetric(c.HostResource, prometheus.GaugeValue, 1, lshost.HOST_NAME, resource)
+				continue
+			}
+			if value, ok := ConvertLoadValue(v); ok {
+				ch <- prometheus.MustNewConstMetric(c.HostNumericResource, prometheus.GaugeValue, value, lshost.HOST_NAME, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *lshostsCollector) parselshostsTopology(ch chan<- prometheus.Metric) error {
+	output, err := lsfOutput(c.logger, "lshosts", "-T")
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+
+	for _, topology := range lshostsTopology_ParseOutput(output) {
+		for _, host := range topology.HOST_NAMES {
+			ch <- prometheus.MustNewConstMetric(c.HostSockets, prometheus.GaugeValue, topology.SOCKETS, host)
+			ch <- prometheus.MustNewConstMetric(c.HostCores, prometheus.GaugeValue, topology.CORES, host)
+			ch <- prometheus.MustNewConstMetric(c.HostThreads, prometheus.GaugeValue, topology.THREADS, host)
+			for node, v := range topology.NUMA {
+				if memory, ok := ConvertLoadValue(v); ok {
+					ch <- prometheus.MustNewConstMetric(c.HostNumaMemory, prometheus.GaugeValue, memory, host, node)
+				}
+			}
+		}
 	}
 
 	return nil
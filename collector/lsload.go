@@ -1,16 +1,13 @@
 package collector
 
 import (
-	"bytes"
-	"encoding/csv"
 	"fmt"
-	"io"
 	"strconv"
 	"strings"
 
+	kingpin "github.com/alecthomas/kingpin/v2"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
-	"github.com/jszwec/csvutil"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -21,9 +18,14 @@ type lsLoadCollector struct {
 	LsLoadut         *prometheus.Desc
 	LsLoadls         *prometheus.Desc
 	LsLoadHostStatus *prometheus.Desc
+	LsLoadIndex      *prometheus.Desc
 	logger           log.Logger
 }
 
+var (
+	lsloadIndices = kingpin.Flag("collector.lsload.indices", "Colon separated list of the load indices passed to `lsload -I`, e.g. r15s:r1m:ut:mem. All the load indices are exported by default.").Default("").String()
+)
+
 func init() {
 	registerCollector("lsload", defaultEnabled, NewLSFlsLoadCollector)
 }
@@ -49,7 +51,7 @@ func NewLSFlsLoadCollector(logger log.Logger) (Collector, error) {
 		),
 		LsLoadut: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "lsload", "ut"),
-			"The CPU utilization exponentially averaged over the last minute, 0 - 1.",
+			"The CPU utilization exponentially averaged over the last minute, 0 - 1. -1 indicates that the value is unavailable.",
 			[]string{"host_name"}, nil,
 		),
 		LsLoadls: prometheus.NewDesc(
@@ -62,6 +64,11 @@ func NewLSFlsLoadCollector(logger log.Logger) (Collector, error) {
 			"The status of the host and the sbatchd daemon. Batch jobs can be dispatched only to hosts with an ok status. Host status has the following, 0:Unknow, 1:ok, 2:unavail, 3:unreach, 4:closed/closed_full, 5:closed_cu_excl",
 			[]string{"host_name"}, nil,
 		),
+		LsLoadIndex: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lsload", "index"),
+			"The value of every load index of lsload -l, including the external load indices reported by ELIMs. Values with a unit are converted to KB, unavailable values are not exported.",
+			[]string{"host_name", "index"}, nil,
+		),
 		logger: logger,
 	}, nil
 }
@@ -83,33 +90,35 @@ func (c *lsLoadCollector) Update(ch chan<- prometheus.Metric) error {
 	return nil
 }
 
-func lsload_CsvtoStruct(lsfOutput []byte, logger log.Logger) ([]lsloadInfo, error) {
-	csv_out := csv.NewReader(TrimReader{bytes.NewReader(lsfOutput)})
-	csv_out.LazyQuotes = true
-	csv_out.Comma = ' '
-	csv_out.TrimLeadingSpace = true
-
-	dec, err := csvutil.NewDecoder(csv_out)
-	if err != nil {
-		level.Error(logger).Log("err=", err)
-		return nil, nil
-	}
-
-	var lsloadInfos []lsloadInfo
-
-	for {
-		var u lsloadInfo
-		if err := dec.Decode(&u); err == io.EOF {
-			break
-		} else if err != nil {
-			level.Error(logger).Log("err=", err)
-			return nil, nil
+// lsload_ParseOutput parses the output of `lsload -w -l` into one map per host,
+// keyed by the column names. The columns are discovered from the header so
+// that external load indices reported by ELIMs are kept, the missing columns
+// of unavailable hosts are left out of the map. It is also used for the other
+// tables with a HOST_NAME column, e.g. `lshosts -w`.
+func lsload_ParseOutput(lsfOutput []byte) []map[string]string {
+	var rows []map[string]string
+	var header []string
+
+	for _, line := range strings.Split(string(lsfOutput), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case fields[0] == "HOST_NAME":
+			header = fields
+		case header == nil:
+			continue
+		default:
+			row := make(map[string]string, len(header))
+			for i, column := range header {
+				if i < len(fields) {
+					row[column] = fields[i]
+				}
+			}
+			rows = append(rows, row)
 		}
-
-		lsloadInfos = append(lsloadInfos, u)
 	}
-	return lsloadInfos, nil
-
+	return rows
 }
 
 func FormatlsLoadStatus(status string, logger log.Logger) float64 {
@@ -144,30 +153,83 @@ func ConvertUT(data string, logger log.Logger) float64 {
 	return fl
 }
 
-func (c *lsLoadCollector) parselsLoad(ch chan<- prometheus.Metric) error {
-	output, err := lsfOutput(c.logger, "lsload", "-w")
+// ConvertLoadValue converts a value printed by the LSF commands, e.g. 0.5, 12%,
+// 7556M or 1.2G, into a float64. Values with a unit are converted to KB like
+// FormatlshostsUnit. "-" and other unavailable values return false.
+func ConvertLoadValue(data string) (float64, bool) {
+	data_new := strings.TrimSuffix(strings.Trim(data, "*"), "%")
+	if data_new == "" || data_new == "-" {
+		return 0, false
+	}
+
+	if fl, err := strconv.ParseFloat(data_new, 64); err == nil {
+		return fl, true
+	}
+
+	// e.g. 7556M or 10GB
+	data_new = strings.TrimSuffix(strings.ToUpper(data_new), "B")
+	if data_new == "" {
+		return 0, false
+	}
+	fl, err := strconv.ParseFloat(data_new[:len(data_new)-1], 64)
 	if err != nil {
-		level.Error(c.logger).Log("err: ", err)
-		return nil
+		return 0, false
+	}
+	size := FormatlshostsUnit(fl, data_new[len(data_new)-1:])
+	if size < 0 {
+		return 0, false
+	}
+	return size, true
+}
+
+func (c *lsLoadCollector) parselsLoad(ch chan<- prometheus.Metric) error {
+	args := []string{"-w", "-l"}
+	if *lsloadIndices != "" {
+		args = append(args, "-I", *lsloadIndices)
 	}
-	lsloads, err := lsload_CsvtoStruct(output, c.logger)
+	output, err := lsfOutput(c.logger, "lsload", args...)
 	if err != nil {
 		level.Error(c.logger).Log("err: ", err)
 		return nil
 	}
 
-	for _, lsload := range lsloads {
-		ch <- prometheus.MustNewConstMetric(c.LsLoadR15s, prometheus.GaugeValue, lsload.R15S, lsload.Name)
-		ch <- prometheus.MustNewConstMetric(c.LsLoadR1m, prometheus.GaugeValue, lsload.R1M, lsload.Name)
-		ch <- prometheus.MustNewConstMetric(c.LsLoadR15m, prometheus.GaugeValue, lsload.R15M, lsload.Name)
-		ch <- prometheus.MustNewConstMetric(c.LsLoadut, prometheus.GaugeValue, ConvertUT(lsload.UT, c.logger), lsload.Name)
-		ch <- prometheus.MustNewConstMetric(c.LsLoadls, prometheus.GaugeValue, lsload.LS, lsload.Name)
-		ch <- prometheus.MustNewConstMetric(c.LsLoadHostStatus, prometheus.GaugeValue, FormatbhostsStatus(lsload.STATUS, c.logger), lsload.Name)
-		// ch <- prometheus.MustNewConstMetric(c.JobRuningCount, prometheus.GaugeValue, bhost.RUN, bhost.HOST_NAME)
-		// ch <- prometheus.MustNewConstMetric(c.JobMaxJobCount, prometheus.GaugeValue, bhost.MAX, bhost.HOST_NAME)
-		// ch <- prometheus.MustNewConstMetric(c.JobSSUSPJobCount, prometheus.GaugeValue, bhost.SSUSP, bhost.HOST_NAME)
-		// ch <- prometheus.MustNewConstMetric(c.JobUSUSPJobCount, prometheus.GaugeValue, bhost.USUSP, bhost.HOST_NAME)
+	builtin := map[string]*prometheus.Desc{
+		"r15s": c.LsLoadR15s,
+		"r1m":  c.LsLoadR1m,
+		"r15m": c.LsLoadR15m,
+		"ut":   c.LsLoadut,
+		"ls":   c.LsLoadls,
+	}
+
+	// An unavailable ut is exported as -1 like before lsload -l was parsed,
+	// unless ut is left out by --collector.lsload.indices.
+	utSelected := *lsloadIndices == ""
+	for _, index := range strings.Split(*lsloadIndices, ":") {
+		if index == "ut" {
+			utSelected = true
+		}
+	}
 
+	for _, lsload := range lsload_ParseOutput(output) {
+		name := lsload["HOST_NAME"]
+		ch <- prometheus.MustNewConstMetric(c.LsLoadHostStatus, prometheus.GaugeValue, FormatbhostsStatus(lsload["status"], c.logger), name)
+
+		if _, ok := ConvertLoadValue(lsload["ut"]); !ok && utSelected {
+			ch <- prometheus.MustNewConstMetric(c.LsLoadut, prometheus.GaugeValue, -1, name)
+		}
+		for index, v := range lsload {
+			if index == "HOST_NAME" || index == "status" {
+				continue
+			}
+			value, ok := ConvertLoadValue(v)
+			if !ok {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(c.LsLoadIndex, prometheus.GaugeValue, value, name, index)
+			if desc, ok := builtin[index]; ok {
+				ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, name)
+			}
+		}
 	}
 
 	return nil
@@ -0,0 +1,212 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	kingpin "github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	perfmonAutostart = kingpin.Flag("collector.perfmon.autostart", "Run `badmin perfmon start` when perfmon is not active, the exporter user must be an LSF administrator.").Default("false").Bool()
+	perfmonTimeout   = kingpin.Flag("collector.perfmon.timeout", "Timeout of badmin perfmon, a command taking longer is killed and fails.").Default("30s").Duration()
+)
+
+// perfmonName returns the name label of a perfmon metric, e.g.
+// job_submission_requests for "Job submission requests".
+func perfmonName(name string) string {
+	return strings.Trim(PerfmonNameRegex.ReplaceAllString(strings.ToLower(name), "_"), "_")
+}
+
+// perfmonFDTable is the table of the file descriptors of mbatchd.
+const perfmonFDTable = "file_descriptor"
+
+// perfmonRow is a metric of a table of badmin perfmon view.
+type perfmonRow struct {
+	table  string
+	name   string
+	values map[string]float64
+}
+
+// perfmon_ParseOutput parses the tables of `badmin perfmon view`. The header
+// of every table names the columns, e.g. Metrics Last Max Min Avg Total (or
+// Current instead of Last) or File Descriptor Metrics Free Used Total, the
+// rows hold the metric name followed by one value per column. The table is
+// named after the header without Metrics, requests for the first table.
+func perfmon_ParseOutput(lsfOutput []byte) []perfmonRow {
+	var rows []perfmonRow
+	var table string
+	var columns []string
+
+	for _, line := range strings.Split(string(lsfOutput), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "---") {
+			continue
+		}
+
+		// The trailing numeric fields are the values.
+		n := 0
+		for n < len(fields) {
+			if _, err := strconv.ParseFloat(fields[len(fields)-1-n], 64); err != nil {
+				break
+			}
+			n++
+		}
+		if n == 0 {
+			// A header, the last words are the column names.
+			for i, f := range fields {
+				switch f {
+				case "Current", "Last", "Free":
+					table = perfmonName(strings.TrimSuffix(strings.Join(fields[:i], " "), "Metrics"))
+					if table == "" {
+						table = "requests"
+					}
+					columns = nil
+					for _, c := range fields[i:] {
+						columns = append(columns, strings.ToLower(c))
+					}
+				}
+			}
+			continue
+		}
+		if columns == nil || n < len(columns) || n == len(fields) {
+			continue
+		}
+
+		row := perfmonRow{table: table, name: perfmonName(strings.Join(fields[:len(fields)-len(columns)], " ")), values: map[string]float64{}}
+		for i, c := range columns {
+			row.values[c], _ = strconv.ParseFloat(fields[len(fields)-len(columns)+i], 64)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// perfmonDenied are the messages of LSF, in lower case, refusing badmin to a
+// user that is not an LSF administrator.
+var perfmonDenied = []string{
+	"permission denied",
+	"you must be lsf administrator",
+	"you must be the lsf administrator",
+	"not an lsf administrator",
+}
+
+// isPermissionDenied reports whether LSF refused a command because the user
+// is not an LSF administrator.
+func isPermissionDenied(output []byte) bool {
+	msg := strings.ToLower(string(output))
+	for _, denied := range perfmonDenied {
+		if strings.Contains(msg, denied) {
+			return true
+		}
+	}
+	return false
+}
+
+type perfmonCollector struct {
+	Active          *prometheus.Desc
+	Value           *prometheus.Desc
+	FileDescriptors *prometheus.Desc
+	// autostart is cleared when the exporter user may not start perfmon.
+	autostart bool
+	mtx       sync.Mutex
+	logger    log.Logger
+}
+
+func init() {
+	registerCollector("perfmon", false, NewLSFPerfmonCollector)
+}
+
+// NewLSFPerfmonCollector returns a new Collector exposing the mbatchd
+// performance metrics of badmin perfmon view.
+func NewLSFPerfmonCollector(logger log.Logger) (Collector, error) {
+
+	return &perfmonCollector{
+		Active: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "perfmon", "active"),
+			"1 if the performance monitor of mbatchd is collecting metrics.",
+			nil, nil,
+		),
+		Value: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "perfmon", "value"),
+			"A metric of badmin perfmon view, the request and job counts of the sample period and the scheduler metrics, by its last (or current), max, min, avg and total statistic.",
+			[]string{"table", "name", "stat"}, nil,
+		),
+		FileDescriptors: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "perfmon", "file_descriptors"),
+			"The free, used and total file descriptors of mbatchd.",
+			[]string{"name", "state"}, nil,
+		),
+		autostart: *perfmonAutostart,
+		logger:    logger,
+	}, nil
+}
+
+// Update calls (*perfmonCollector).parsePerfmon to get the perfmon metrics.
+func (c *perfmonCollector) Update(ch chan<- prometheus.Metric) error {
+	err := c.parsePerfmon(ch)
+	if err != nil {
+		return fmt.Errorf("couldn't get perfmon infomation: %w", err)
+	}
+
+	return nil
+}
+
+// startPerfmon runs `badmin perfmon start`. Autostart is switched off when
+// the exporter user is not an LSF administrator.
+func (c *perfmonCollector) startPerfmon() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if !c.autostart {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *perfmonTimeout)
+	defer cancel()
+	output, err := lsfCombinedOutput(ctx, c.logger, "badmin", "perfmon", "start")
+	if isPermissionDenied(output) {
+		c.autostart = false
+		level.Error(c.logger).Log("err: ", "the exporter user is not an LSF administrator and cannot start perfmon, disabling --collector.perfmon.autostart", "output", strings.TrimSpace(string(output)))
+		return
+	}
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return
+	}
+	level.Info(c.logger).Log("msg", "started the performance monitor of mbatchd")
+}
+
+func (c *perfmonCollector) parsePerfmon(ch chan<- prometheus.Metric) error {
+	ctx, cancel := context.WithTimeout(context.Background(), *perfmonTimeout)
+	defer cancel()
+	output, err := lsfCombinedOutput(ctx, c.logger, "badmin", "perfmon", "view")
+	rows := perfmon_ParseOutput(output)
+	if len(rows) == 0 {
+		// perfmon is not active or badmin failed.
+		ch <- prometheus.MustNewConstMetric(c.Active, prometheus.GaugeValue, 0)
+		if err != nil {
+			level.Error(c.logger).Log("err: ", err)
+		}
+		c.startPerfmon()
+		return nil
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.Active, prometheus.GaugeValue, 1)
+	for _, row := range rows {
+		for stat, v := range row.values {
+			if row.table == perfmonFDTable {
+				ch <- prometheus.MustNewConstMetric(c.FileDescriptors, prometheus.GaugeValue, v, row.name, stat)
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(c.Value, prometheus.GaugeValue, v, row.table, row.name, stat)
+		}
+	}
+
+	return nil
+}
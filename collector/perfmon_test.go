@@ -0,0 +1,82 @@
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func TestPerfmonParseOutput(t *testing.T) {
+	data, err := os.ReadFile("fixtures/perfmon_view.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := perfmon_ParseOutput(data)
+	if len(rows) != 15 {
+		t.Fatalf("got %d rows, want 15", len(rows))
+	}
+
+	byName := map[string]perfmonRow{}
+	for _, row := range rows {
+		byName[row.name] = row
+	}
+	requests := byName["processed_requests_mbatchd"]
+	if requests.table != "requests" {
+		t.Errorf("table of processed_requests_mbatchd: got %q, want requests", requests.table)
+	}
+	for stat, want := range map[string]float64{"last": 0, "max": 25, "min": 0, "avg": 8, "total": 159} {
+		if got, ok := requests.values[stat]; !ok || got != want {
+			t.Errorf("%s of processed_requests_mbatchd: got %v, want %v", stat, got, want)
+		}
+	}
+
+	for name, want := range map[string]map[string]float64{
+		"scheduling_interval_in_second_s":        {"last": 5, "max": 12, "min": 5, "avg": 6},
+		"slot_scheduling_cycle_time_in_second_s": {"last": 1, "max": 4, "min": 0, "avg": 2},
+		"job_scheduling_cycle_time_in_second_s":  {"last": 0, "max": 3, "min": 0, "avg": 1},
+	} {
+		row := byName[name]
+		if row.table != "scheduler" || len(row.values) != len(want) {
+			t.Errorf("%s: got %+v, want the scheduler table with %v", name, row, want)
+			continue
+		}
+		for stat, v := range want {
+			if got := row.values[stat]; got != v {
+				t.Errorf("%s of %s: got %v, want %v", stat, name, got, v)
+			}
+		}
+	}
+
+	fd := byName["mbd_file_descriptor_usage"]
+	if fd.table != perfmonFDTable {
+		t.Errorf("table of mbd_file_descriptor_usage: got %q, want %s", fd.table, perfmonFDTable)
+	}
+	for stat, want := range map[string]float64{"free": 800, "used": 45, "total": 845} {
+		if got := fd.values[stat]; got != want {
+			t.Errorf("%s of mbd_file_descriptor_usage: got %v, want %v", stat, got, want)
+		}
+	}
+}
+
+func TestPerfmonParseOutputCurrent(t *testing.T) {
+	rows := perfmon_ParseOutput([]byte(`
+                                 Current   Max       Min       Avg
+Job submission requests             1        10         0         2
+`))
+	if len(rows) != 1 || rows[0].table != "requests" || rows[0].values["current"] != 1 {
+		t.Errorf("got %+v, want job_submission_requests of requests with current 1", rows)
+	}
+}
+
+func TestIsPermissionDenied(t *testing.T) {
+	for output, want := range map[string]bool{
+		"badmin: Permission denied.\n":                          true,
+		"User permission denied.\n":                             true,
+		"You must be LSF administrator to run this command.":    true,
+		"Performance monitor has been started.\n":               false,
+		"Contact your LSF administrator for the sample period.": false,
+	} {
+		if got := isPermissionDenied([]byte(output)); got != want {
+			t.Errorf("isPermissionDenied(%q): got %v, want %v", output, got, want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package collector
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Reasons of a job suspended by preemption, the reason field of JOB_STATUS.
+const (
+	suspReschedPreempt = 0x10
+	suspMbdPreempt     = 0x80
+	suspSbdPreempt     = 0x100
+)
+
+// preemptionWindow is the time in seconds between the preemption of a job and
+// the start of another job on the same host within which the started job is
+// taken as the preempting job. The events do not name the preempting job.
+const preemptionWindow = 60
+
+type preemptionKey struct {
+	preempting, preempted string
+}
+
+// hostStart is the latest job started on a host.
+type hostStart struct {
+	job, queue string
+	time       float64
+}
+
+// pendingPreemption is a preemption whose preempting job has not started yet.
+type pendingPreemption struct {
+	host, queue string
+	time        float64
+}
+
+// preemptionMetrics counts the preemptions and requeues of the jobs and the
+// run time they lost.
+type preemptionMetrics struct {
+	Preemptions *prometheus.Desc
+	Requeues    *prometheus.Desc
+	LostTime    *prometheus.Desc
+	preemptions map[preemptionKey]float64
+	requeues    map[[2]string]float64
+	lost        map[string]float64
+	starts      map[string]hostStart
+	pending     []pendingPreemption
+	// now is the time of the latest event.
+	now float64
+}
+
+func newPreemptionMetrics(subsystem, source string) *preemptionMetrics {
+	return &preemptionMetrics{
+		Preemptions: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "preemptions_total"),
+			"The number of jobs preempted according to the events of "+source+". The preempting queue is the queue of the next job started on the host of the preempted job within a minute, unknown if there is none.",
+			[]string{"preempting_queue", "preempted_queue"}, nil,
+		),
+		Requeues: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "requeues_total"),
+			"The number of started jobs requeued to pending by their exit code, none for jobs requeued by brequeue.",
+			[]string{"queue", "exit_code"}, nil,
+		),
+		LostTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "preemption_lost_seconds_total"),
+			"The run time lost by preempted jobs, the time suspended jobs waited to resume and the time killed or requeued jobs had run.",
+			[]string{"queue"}, nil,
+		),
+		preemptions: map[preemptionKey]float64{},
+		requeues:    map[[2]string]float64{},
+		lost:        map[string]float64{},
+		starts:      map[string]hostStart{},
+	}
+}
+
+// started records the host of a started job and attributes the pending
+// preemptions of the host to the queue of the job.
+func (p *preemptionMetrics) started(jobID string, job *lsbJob, r lsbRecord, count bool) {
+	// jobId jStatus jobPid jobPGid hostFactor numExHosts execHosts...
+	t, _ := r.float(2)
+	p.now = t
+	job.HOST = r.field(9)
+	job.START_TIME = t
+	job.PREEMPTED_AT = 0
+	p.starts[job.HOST] = hostStart{job: jobID, queue: job.QUEUE, time: t}
+
+	var pending []pendingPreemption
+	for _, preemption := range p.pending {
+		if preemption.host == job.HOST && t-preemption.time <= preemptionWindow {
+			if count {
+				p.preemptions[preemptionKey{job.QUEUE, preemption.queue}]++
+			}
+			continue
+		}
+		pending = append(pending, preemption)
+	}
+	p.pending = pending
+}
+
+// preempted counts the preemption of a job, the run time of a killed job is
+// lost at once and the time a suspended job waits when it resumes.
+func (p *preemptionMetrics) preempted(jobID string, job *lsbJob, t float64, killed bool, count bool) {
+	if killed {
+		if count && job.START_TIME > 0 {
+			p.lost[job.QUEUE] += t - job.START_TIME
+		}
+		job.PREEMPTED_AT = 0
+	} else {
+		job.PREEMPTED_AT = t
+	}
+	if !count {
+		return
+	}
+
+	if start, ok := p.starts[job.HOST]; ok && start.job != jobID && t-start.time <= preemptionWindow {
+		p.preemptions[preemptionKey{start.queue, job.QUEUE}]++
+		return
+	}
+	p.pending = append(p.pending, pendingPreemption{host: job.HOST, queue: job.QUEUE, time: t})
+}
+
+// statusChanged follows the suspension, resume and requeue of a job from a
+// JOB_STATUS record.
+func (p *preemptionMetrics) statusChanged(jobID string, job *lsbJob, r lsbRecord, previous string, count bool) {
+	// jobId jStatus reason subreasons cpuTime endTime ru lsfRusage... jFlags
+	// exitStatus
+	t, _ := r.float(2)
+	p.now = t
+	jStatus, _ := r.int(4)
+	reason, _ := r.int(5)
+	status := jobStatusName(jStatus)
+
+	switch {
+	case status == "ssusp" && previous != "ssusp" && reason&(suspReschedPreempt|suspMbdPreempt|suspSbdPreempt) != 0:
+		p.preempted(jobID, job, t, false, count)
+	case status == "run" && job.PREEMPTED_AT > 0:
+		if count {
+			p.lost[job.QUEUE] += t - job.PREEMPTED_AT
+		}
+		job.PREEMPTED_AT = 0
+	case status == "pend" && previous != "" && previous != "pend" && previous != "psusp":
+		if job.PREEMPTED_AT > 0 && job.START_TIME > 0 && count {
+			p.lost[job.QUEUE] += t - job.START_TIME
+		}
+		job.PREEMPTED_AT = 0
+		if job.REQUEUED {
+			// Already counted by the JOB_REQUEUE event of brequeue.
+			job.REQUEUED = false
+			return
+		}
+		exitStatus := 11
+		if ru, _ := r.int(9); ru != 0 {
+			exitStatus += 19
+		}
+		code := "unknown"
+		if v, ok := r.int(exitStatus); ok {
+			code = exitCode(v)
+		}
+		if count {
+			p.requeues[[2]string{job.QUEUE, code}]++
+		}
+	case status == "done" || status == "exit":
+		job.PREEMPTED_AT = 0
+	}
+}
+
+// signaled counts the jobs killed by preemption from a JOB_SIGNAL record.
+func (p *preemptionMetrics) signaled(jobID string, job *lsbJob, r lsbRecord, count bool) {
+	// jobId userId runCount signalSymbol
+	t, _ := r.float(2)
+	p.now = t
+	if strings.Contains(strings.ToUpper(r.field(6)), "PREEMPT") {
+		p.preempted(jobID, job, t, true, count)
+	}
+}
+
+// requeued counts a job requeued by brequeue from a JOB_REQUEUE record.
+func (p *preemptionMetrics) requeued(job *lsbJob, count bool) {
+	if count {
+		p.requeues[[2]string{job.QUEUE, "none"}]++
+	}
+	job.REQUEUED = true
+}
+
+func (p *preemptionMetrics) collect(ch chan<- prometheus.Metric) {
+	// Preemptions without a started job on the host within the window are
+	// counted with an unknown preempting queue.
+	var pending []pendingPreemption
+	for _, preemption := range p.pending {
+		if p.now-preemption.time > preemptionWindow {
+			p.preemptions[preemptionKey{"unknown", preemption.queue}]++
+			continue
+		}
+		pending = append(pending, preemption)
+	}
+	p.pending = pending
+
+	for k, v := range p.preemptions {
+		ch <- prometheus.MustNewConstMetric(p.Preemptions, prometheus.CounterValue, v, k.preempting, k.preempted)
+	}
+	for k, v := range p.requeues {
+		ch <- prometheus.MustNewConstMetric(p.Requeues, prometheus.CounterValue, v, k[0], k[1])
+	}
+	for queue, v := range p.lost {
+		ch <- prometheus.MustNewConstMetric(p.LostTime, prometheus.CounterValue, v, queue)
+	}
+}
@@ -0,0 +1,125 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	kingpin "github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	probeInterval = kingpin.Flag("collector.probe.interval", "Interval of the probes of LIM (lsid) and mbatchd (bhosts -w <master>).").Default("30s").Duration()
+	probeTimeout  = kingpin.Flag("collector.probe.timeout", "Timeout of a probe command, a command taking longer is killed and fails.").Default("10s").Duration()
+)
+
+// probeResult is the result of the latest run of a probe command.
+type probeResult struct {
+	duration  float64
+	success   bool
+	timestamp time.Time
+}
+
+type probeCollector struct {
+	Duration  *prometheus.Desc
+	Success   *prometheus.Desc
+	Timestamp *prometheus.Desc
+	results   map[string]probeResult
+	master    string
+	mtx       sync.Mutex
+	logger    log.Logger
+}
+
+func init() {
+	registerCollector("probe", false, NewLSFProbeCollector)
+}
+
+// NewLSFProbeCollector returns a new Collector exposing the response times of
+// LIM and mbatchd, probed in the background independently of the scrapes.
+func NewLSFProbeCollector(logger log.Logger) (Collector, error) {
+
+	c := &probeCollector{
+		Duration: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "probe", "duration_seconds"),
+			"The duration of the latest probe command, lsid for LIM and bhosts -w <master> for mbatchd.",
+			[]string{"command"}, nil,
+		),
+		Success: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "probe", "success"),
+			"1 if the latest probe command succeeded within --collector.probe.timeout.",
+			[]string{"command"}, nil,
+		),
+		Timestamp: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "probe", "timestamp_seconds"),
+			"The time of the latest probe command since the epoch.",
+			[]string{"command"}, nil,
+		),
+		results: map[string]probeResult{},
+		logger:  logger,
+	}
+	go c.run()
+
+	return c, nil
+}
+
+// Update calls (*probeCollector).parseProbes to get the probe metrics.
+func (c *probeCollector) Update(ch chan<- prometheus.Metric) error {
+	err := c.parseProbes(ch)
+	if err != nil {
+		return fmt.Errorf("couldn't get probe infomation: %w", err)
+	}
+
+	return nil
+}
+
+// probeCommand runs a probe command with the timeout and records its result.
+func (c *probeCollector) probeCommand(exe_file string, args ...string) ([]byte, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), *probeTimeout)
+	defer cancel()
+
+	begin := time.Now()
+	output, err := lsfOutputContext(ctx, c.logger, exe_file, args...)
+	result := probeResult{duration: time.Since(begin).Seconds(), success: err == nil, timestamp: begin}
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+	}
+
+	c.mtx.Lock()
+	c.results[exe_file] = result
+	c.mtx.Unlock()
+	return output, err == nil
+}
+
+// run probes LIM and mbatchd every --collector.probe.interval. The master
+// host probed with bhosts is taken from the latest successful lsid.
+func (c *probeCollector) run() {
+	for {
+		if output, ok := c.probeCommand("lsid"); ok {
+			if matches := MasterNameRegex.FindStringSubmatch(string(output)); matches != nil {
+				c.master = matches[MasterNameRegex.SubexpIndex("master_name")]
+			}
+		}
+		if c.master != "" {
+			c.probeCommand("bhosts", "-w", c.master)
+		}
+
+		time.Sleep(*probeInterval)
+	}
+}
+
+func (c *probeCollector) parseProbes(ch chan<- prometheus.Metric) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for command, result := range c.results {
+		ch <- prometheus.MustNewConstMetric(c.Duration, prometheus.GaugeValue, result.duration, command)
+		ch <- prometheus.MustNewConstMetric(c.Success, prometheus.GaugeValue, boolToFloat(result.success), command)
+		ch <- prometheus.MustNewConstMetric(c.Timestamp, prometheus.GaugeValue, float64(result.timestamp.Unix()), command)
+	}
+
+	return nil
+}
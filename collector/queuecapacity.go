@@ -0,0 +1,170 @@
+package collector
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type queueCapacityCollector struct {
+	QueueHosts       *prometheus.Desc
+	QueueMaxSlots    *prometheus.Desc
+	QueueUsedSlots   *prometheus.Desc
+	QueueFreeSlots   *prometheus.Desc
+	QueueHostStatus  *prometheus.Desc
+	QueueUnavailable *prometheus.Desc
+	logger           log.Logger
+}
+
+// queueCapacityMaxAge is the time the queue capacities are reused, so that
+// the collectors of the same scrape run the LSF commands only once.
+const queueCapacityMaxAge = 10 * time.Second
+
+var queueCapacityCache struct {
+	capacities map[string]*queueCapacity
+	time       time.Time
+	mtx        sync.Mutex
+}
+
+func init() {
+	registerCollector("queue_capacity", false, NewLSFQueueCapacityCollector)
+}
+
+// NewLSFQueueCapacityCollector returns a new Collector exposing the slots of
+// the hosts eligible for every queue.
+func NewLSFQueueCapacityCollector(logger log.Logger) (Collector, error) {
+
+	return &queueCapacityCollector{
+		QueueHosts: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue_capacity", "hosts"),
+			"The number of hosts eligible for the queue, resolved from the HOSTS of bqueues -l.",
+			[]string{"queues_name"}, nil,
+		),
+		QueueMaxSlots: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue_capacity", "maxjob_count"),
+			"The number of configured job slots on the hosts eligible for the queue. Hosts without a limit are not counted.",
+			[]string{"queues_name"}, nil,
+		),
+		QueueUsedSlots: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue_capacity", "used_slots"),
+			"The number of job slots used by the jobs of all queues on the hosts eligible for the queue.",
+			[]string{"queues_name"}, nil,
+		),
+		QueueFreeSlots: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue_capacity", "free_slots"),
+			"The number of free job slots on the ok hosts eligible for the queue.",
+			[]string{"queues_name"}, nil,
+		),
+		QueueHostStatus: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue_capacity", "host_status_count"),
+			"The number of hosts eligible for the queue in each bhosts status.",
+			[]string{"queues_name", "status"}, nil,
+		),
+		QueueUnavailable: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue_capacity", "unavailable"),
+			"1 if none of the hosts eligible for the queue is ok, i.e. all of them are closed or unavailable.",
+			[]string{"queues_name"}, nil,
+		),
+		logger: logger,
+	}, nil
+}
+
+// Update calls (*queueCapacityCollector).parseQueueCapacity to get the queue
+// capacity metrics.
+func (c *queueCapacityCollector) Update(ch chan<- prometheus.Metric) error {
+	err := c.parseQueueCapacity(ch)
+	if err != nil {
+		return fmt.Errorf("couldn't get queue capacity infomation: %w", err)
+	}
+
+	return nil
+}
+
+// queueCapacities returns the capacity of the hosts eligible for every queue,
+// joined from bqueues -l, bmgroup, bhosts, lshosts and lsload.
+func queueCapacities(logger log.Logger) (map[string]*queueCapacity, error) {
+	hosts, err := hostCapacities(logger)
+	if err != nil {
+		return nil, err
+	}
+	allHosts := make([]string, 0, len(hosts))
+	for name := range hosts {
+		allHosts = append(allHosts, name)
+	}
+	eligible, err := queueHosts(logger, allHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	capacities := make(map[string]*queueCapacity, len(eligible))
+	for queue, members := range eligible {
+		capacity := &queueCapacity{STATUS: map[string]float64{}}
+		for _, name := range members {
+			host, ok := hosts[name]
+			if !ok {
+				continue
+			}
+			capacity.HOSTS = append(capacity.HOSTS, name)
+			capacity.STATUS[host.STATUS]++
+			if host.MAX > 0 {
+				capacity.MAX += host.MAX
+			}
+			capacity.USED += host.NJOBS
+			if host.STATUS != "ok" {
+				continue
+			}
+			capacity.OK_HOSTS++
+			if host.MAX > host.NJOBS {
+				capacity.FREE += host.MAX - host.NJOBS
+			}
+			if host.HAS_LOAD {
+				capacity.FREE_MEMORY += host.MEM
+			}
+		}
+		capacities[queue] = capacity
+	}
+	return capacities, nil
+}
+
+// cachedQueueCapacities returns the queue capacities computed within
+// queueCapacityMaxAge or computes them with queueCapacities.
+func cachedQueueCapacities(logger log.Logger) (map[string]*queueCapacity, error) {
+	queueCapacityCache.mtx.Lock()
+	defer queueCapacityCache.mtx.Unlock()
+
+	if time.Since(queueCapacityCache.time) < queueCapacityMaxAge {
+		return queueCapacityCache.capacities, nil
+	}
+	capacities, err := queueCapacities(logger)
+	if err != nil {
+		return nil, err
+	}
+	queueCapacityCache.capacities = capacities
+	queueCapacityCache.time = time.Now()
+	return capacities, nil
+}
+
+func (c *queueCapacityCollector) parseQueueCapacity(ch chan<- prometheus.Metric) error {
+	capacities, err := cachedQueueCapacities(c.logger)
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+
+	for queue, capacity := range capacities {
+		ch <- prometheus.MustNewConstMetric(c.QueueHosts, prometheus.GaugeValue, float64(len(capacity.HOSTS)), queue)
+		ch <- prometheus.MustNewConstMetric(c.QueueMaxSlots, prometheus.GaugeValue, capacity.MAX, queue)
+		ch <- prometheus.MustNewConstMetric(c.QueueUsedSlots, prometheus.GaugeValue, capacity.USED, queue)
+		ch <- prometheus.MustNewConstMetric(c.QueueFreeSlots, prometheus.GaugeValue, capacity.FREE, queue)
+		ch <- prometheus.MustNewConstMetric(c.QueueUnavailable, prometheus.GaugeValue, boolToFloat(capacity.OK_HOSTS == 0), queue)
+		for status, count := range capacity.STATUS {
+			ch <- prometheus.MustNewConstMetric(c.QueueHostStatus, prometheus.GaugeValue, count, queue, status)
+		}
+	}
+
+	return nil
+}
@@ -9,4 +9,19 @@ var (
 	LSFVersionRegex  = regexp.MustCompile(`(?P<lsf_version>\d+.\d+.\d+.\d+)`)
 
 	ResourceRegex = regexp.MustCompile(`\((?P<resource_type>.+)\)`)
+
+	// Regexp to parse the label: value pairs of the SUMMARY of bacct.
+	BacctSummaryRegex = regexp.MustCompile(`(?P<label>[A-Z][A-Za-z ]+?):\s+(?P<value>-?[0-9.]+)`)
+
+	// Regexp to parse the lines of the daemon logs, e.g.
+	// Oct 18 10:07:01 2026 12345 3 10.1 main(): message
+	DaemonLogLineRegex = regexp.MustCompile(`^\w{3}\s+\d+\s+[\d:]+\s+\d{4}\s+(?P<pid>\d+)\s+(?P<level>\d)\s+(?P<version>[\d.]+)\s+(?P<message>.*)$`)
+	// Regexps to normalize the messages of the daemon logs to templates, the
+	// values in angle brackets (jobs, hosts, users) and the words that are
+	// host names or contain digits (numbers, IP addresses) are replaced.
+	LogBracketRegex = regexp.MustCompile(`<[^>]*>`)
+	LogWordRegex    = regexp.MustCompile(`[^\s<>()=,;:'"]+`)
+
+	// Regexp to replace the characters of the perfmon metric names by _.
+	PerfmonNameRegex = regexp.MustCompile(`[^a-z0-9]+`)
 )
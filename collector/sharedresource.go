@@ -0,0 +1,159 @@
+package collector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type sharedResourceCollector struct {
+	ResourceTotal    *prometheus.Desc
+	ResourceReserved *prometheus.Desc
+	ResourceValue    *prometheus.Desc
+	ResourceLocation *prometheus.Desc
+	logger           log.Logger
+}
+
+func init() {
+	registerCollector("shared_resource", false, NewLSFSharedResourceCollector)
+}
+
+// NewLSFSharedResourceCollector returns a new Collector exposing the shared
+// resources of `bhosts -s` and `lsload -s`.
+func NewLSFSharedResourceCollector(logger log.Logger) (Collector, error) {
+
+	return &sharedResourceCollector{
+		ResourceTotal: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "shared_resource", "total"),
+			"The total amount of the shared resource available to batch jobs from bhosts -s. Values with a unit are converted to KB.",
+			[]string{"resource", "location"}, nil,
+		),
+		ResourceReserved: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "shared_resource", "reserved"),
+			"The amount of the shared resource reserved by batch jobs from bhosts -s. Values with a unit are converted to KB.",
+			[]string{"resource", "location"}, nil,
+		),
+		ResourceValue: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "shared_resource", "value"),
+			"The current value of the shared resource from lsload -s. Values with a unit are converted to KB.",
+			[]string{"resource", "location"}, nil,
+		),
+		ResourceLocation: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "shared_resource", "location_member"),
+			"A metric with a constant '1' value for every host of the location of a shared resource instance, [all] is expanded to all hosts.",
+			[]string{"resource", "location", "host_name"}, nil,
+		),
+		logger: logger,
+	}, nil
+}
+
+// Update calls (*sharedResourceCollector).parseSharedResources to get the
+// shared resource metrics.
+func (c *sharedResourceCollector) Update(ch chan<- prometheus.Metric) error {
+	err := c.parseSharedResources(ch)
+	if err != nil {
+		return fmt.Errorf("couldn't get shared resource infomation: %w", err)
+	}
+
+	return nil
+}
+
+// sharedResource_ParseOutput parses the output of `bhosts -s` and `lsload -s`.
+// Every row holds the resource name, the value columns and the hosts of the
+// LOCATION column, long locations continued on the next lines are joined.
+func sharedResource_ParseOutput(lsfOutput []byte) [][]string {
+	var rows [][]string
+	var columns int
+
+	for _, line := range strings.Split(string(lsfOutput), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case fields[0] == "RESOURCE":
+			// RESOURCE TOTAL RESERVED LOCATION or RESOURCE VALUE LOCATION
+			columns = len(fields) - 1
+		case columns == 0:
+			continue
+		case (line[0] == ' ' || line[0] == '\t') && len(rows) > 0:
+			rows[len(rows)-1] = append(rows[len(rows)-1], fields...)
+		case len(fields) >= columns:
+			rows = append(rows, fields)
+		}
+	}
+	return rows
+}
+
+// sharedResourceLocation returns the location label and the hosts of a shared
+// resource instance, the hosts are sorted so that bhosts -s and lsload -s
+// produce the same label.
+func sharedResourceLocation(hosts []string, allHosts []string) (string, []string) {
+	var names []string
+	for _, h := range hosts {
+		h = strings.Trim(h, "[]")
+		if h != "" {
+			names = append(names, h)
+		}
+	}
+	if len(names) == 1 && names[0] == "all" {
+		return "all", allHosts
+	}
+
+	sort.Strings(names)
+	return strings.Join(names, " "), expandHostList(names, nil, allHosts)
+}
+
+func (c *sharedResourceCollector) parseSharedResources(ch chan<- prometheus.Metric) error {
+	output, err := lsfOutput(c.logger, "bhosts", "-w")
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+	bhosts, err := bhost_CsvtoStruct(output, c.logger)
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+	allHosts := make([]string, 0, len(bhosts))
+	for _, bhost := range bhosts {
+		allHosts = append(allHosts, bhost.HOST_NAME)
+	}
+
+	output, err = lsfOutput(c.logger, "bhosts", "-s")
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+	// RESOURCE TOTAL RESERVED LOCATION
+	for _, row := range sharedResource_ParseOutput(output) {
+		location, hosts := sharedResourceLocation(row[3:], allHosts)
+		if total, ok := ConvertLoadValue(row[1]); ok {
+			ch <- prometheus.MustNewConstMetric(c.ResourceTotal, prometheus.GaugeValue, total, row[0], location)
+		}
+		if reserved, ok := ConvertLoadValue(row[2]); ok {
+			ch <- prometheus.MustNewConstMetric(c.ResourceReserved, prometheus.GaugeValue, reserved, row[0], location)
+		}
+		for _, host := range hosts {
+			ch <- prometheus.MustNewConstMetric(c.ResourceLocation, prometheus.GaugeValue, 1, row[0], location, host)
+		}
+	}
+
+	output, err = lsfOutput(c.logger, "lsload", "-s")
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+	// RESOURCE VALUE LOCATION
+	for _, row := range sharedResource_ParseOutput(output) {
+		location, _ := sharedResourceLocation(row[2:], allHosts)
+		if value, ok := ConvertLoadValue(row[1]); ok {
+			ch <- prometheus.MustNewConstMetric(c.ResourceValue, prometheus.GaugeValue, value, row[0], location)
+		}
+	}
+
+	return nil
+}
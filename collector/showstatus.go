@@ -0,0 +1,220 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type showstatusCollector struct {
+	AvailableHosts    *prometheus.Desc
+	ServerCount       *prometheus.Desc
+	ServerStatusCount *prometheus.Desc
+	JobCount          *prometheus.Desc
+	JobStatusCount    *prometheus.Desc
+	UserCount         *prometheus.Desc
+	UserGroupCount    *prometheus.Desc
+	ActiveUserCount   *prometheus.Desc
+	StartTime         *prometheus.Desc
+	ReconfigTime      *prometheus.Desc
+	RestartTime       *prometheus.Desc
+	MbatchdCount      *prometheus.Desc
+	ChildCount        *prometheus.Desc
+	logger            log.Logger
+}
+
+func init() {
+	registerCollector("showstatus", false, NewLSFShowstatusCollector)
+}
+
+// NewLSFShowstatusCollector returns a new Collector exposing the mbatchd
+// runtime summary of badmin showstatus.
+func NewLSFShowstatusCollector(logger log.Logger) (Collector, error) {
+
+	return &showstatusCollector{
+		AvailableHosts: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "showstatus", "available_count"),
+			"The current and peak number of the available local client and server hosts and of their CPUs, cores and slots.",
+			[]string{"resource", "kind"}, nil,
+		),
+		ServerCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "showstatus", "server_count"),
+			"The number of server hosts.",
+			nil, nil,
+		),
+		ServerStatusCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "showstatus", "server_status_count"),
+			"The number of server hosts in each state.",
+			[]string{"status"}, nil,
+		),
+		JobCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "showstatus", "job_count"),
+			"The number of jobs known to mbatchd.",
+			nil, nil,
+		),
+		JobStatusCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "showstatus", "job_status_count"),
+			"The number of jobs in each state.",
+			[]string{"status"}, nil,
+		),
+		UserCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "showstatus", "user_count"),
+			"The number of users.",
+			nil, nil,
+		),
+		UserGroupCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "showstatus", "user_group_count"),
+			"The number of user groups.",
+			nil, nil,
+		),
+		ActiveUserCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "showstatus", "active_user_count"),
+			"The number of users with jobs.",
+			nil, nil,
+		),
+		StartTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "showstatus", "mbatchd_start_time_seconds"),
+			"The latest start time of mbatchd since the epoch.",
+			nil, nil,
+		),
+		ReconfigTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "showstatus", "mbatchd_reconfig_time_seconds"),
+			"The latest reconfig time of mbatchd since the epoch.",
+			nil, nil,
+		),
+		RestartTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "showstatus", "mbatchd_restart_time_seconds"),
+			"The start time of the new mbatchd of a restart in progress (badmin mbdrestart -p) since the epoch.",
+			nil, nil,
+		),
+		MbatchdCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "showstatus", "mbatchd_count"),
+			"The number of active mbatchd processes, 2 while a new mbatchd is started by a parallel restart.",
+			nil, nil,
+		),
+		ChildCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "showstatus", "mbatchd_child_count"),
+			"The number of mbatchd child processes reported by showstatus.",
+			[]string{"child"}, nil,
+		),
+		logger: logger,
+	}, nil
+}
+
+// Update calls (*showstatusCollector).parseShowstatus to get the mbatchd
+// status metrics.
+func (c *showstatusCollector) Update(ch chan<- prometheus.Metric) error {
+	err := c.parseShowstatus(ch)
+	if err != nil {
+		return fmt.Errorf("couldn't get showstatus infomation: %w", err)
+	}
+
+	return nil
+}
+
+// showstatus_ParseOutput returns the "key: value" lines of badmin showstatus.
+// The keys of the indented lines below "Number of servers", "Number of jobs"
+// and "Available local hosts" are prefixed with servers/, jobs/ and hosts/.
+func showstatus_ParseOutput(lsfOutput []byte) map[string]string {
+	values := map[string]string{}
+	section := ""
+	indent := 0
+	for _, line := range strings.Split(string(lsfOutput), "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			section = ""
+			continue
+		}
+		depth := len(line) - len(strings.TrimLeft(line, " \t"))
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch {
+		case key == "number of servers":
+			section, indent = "servers/", depth
+		case key == "number of jobs":
+			section, indent = "jobs/", depth
+		case strings.HasPrefix(key, "available local hosts"):
+			section, indent = "hosts/", depth
+			continue
+		case section != "" && depth > indent:
+			key = section + key
+		default:
+			section = ""
+		}
+		values[key] = value
+	}
+	return values
+}
+
+// showstatusTime parses a time of showstatus, e.g. Thu Nov 22 21:17:01 2012.
+func showstatusTime(value string) (float64, bool) {
+	t, err := time.ParseInLocation("Mon Jan 2 15:04:05 2006", strings.Join(strings.Fields(value), " "), time.Local)
+	if err != nil {
+		return 0, false
+	}
+	return float64(t.Unix()), true
+}
+
+func (c *showstatusCollector) parseShowstatus(ch chan<- prometheus.Metric) error {
+	output, err := lsfOutput(c.logger, "badmin", "showstatus")
+	if err != nil {
+		level.Error(c.logger).Log("err: ", err)
+		return nil
+	}
+
+	mbatchd := 0.0
+	for key, value := range showstatus_ParseOutput(output) {
+		v, numErr := strconv.ParseFloat(value, 64)
+		section, name, _ := strings.Cut(key, "/")
+		switch {
+		case section == "hosts":
+			// current/peak
+			current, peak, _ := strings.Cut(value, "/")
+			if v, err := strconv.ParseFloat(current, 64); err == nil {
+				ch <- prometheus.MustNewConstMetric(c.AvailableHosts, prometheus.GaugeValue, v, name, "current")
+			}
+			if v, err := strconv.ParseFloat(peak, 64); err == nil {
+				ch <- prometheus.MustNewConstMetric(c.AvailableHosts, prometheus.GaugeValue, v, name, "peak")
+			}
+		case numErr == nil && section == "servers" && name != "":
+			ch <- prometheus.MustNewConstMetric(c.ServerStatusCount, prometheus.GaugeValue, v, name)
+		case numErr == nil && section == "jobs" && name != "":
+			ch <- prometheus.MustNewConstMetric(c.JobStatusCount, prometheus.GaugeValue, v, name)
+		case numErr == nil && key == "number of servers":
+			ch <- prometheus.MustNewConstMetric(c.ServerCount, prometheus.GaugeValue, v)
+		case numErr == nil && key == "number of jobs":
+			ch <- prometheus.MustNewConstMetric(c.JobCount, prometheus.GaugeValue, v)
+		case numErr == nil && key == "number of users":
+			ch <- prometheus.MustNewConstMetric(c.UserCount, prometheus.GaugeValue, v)
+		case numErr == nil && key == "number of user groups":
+			ch <- prometheus.MustNewConstMetric(c.UserGroupCount, prometheus.GaugeValue, v)
+		case numErr == nil && key == "number of active users":
+			ch <- prometheus.MustNewConstMetric(c.ActiveUserCount, prometheus.GaugeValue, v)
+		case numErr == nil && strings.Contains(key, "child"):
+			ch <- prometheus.MustNewConstMetric(c.ChildCount, prometheus.GaugeValue, v, strings.TrimPrefix(key, "number of "))
+		case key == "latest mbatchd start":
+			if t, ok := showstatusTime(value); ok {
+				ch <- prometheus.MustNewConstMetric(c.StartTime, prometheus.GaugeValue, t)
+			}
+		case key == "latest mbatchd reconfig":
+			if t, ok := showstatusTime(value); ok {
+				ch <- prometheus.MustNewConstMetric(c.ReconfigTime, prometheus.GaugeValue, t)
+			}
+		case key == "new mbatchd started":
+			if t, ok := showstatusTime(value); ok {
+				ch <- prometheus.MustNewConstMetric(c.RestartTime, prometheus.GaugeValue, t)
+			}
+		case key == "active mbatchd pid" || key == "new mbatchd pid":
+			mbatchd++
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(c.MbatchdCount, prometheus.GaugeValue, mbatchd)
+
+	return nil
+}
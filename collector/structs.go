@@ -86,3 +86,121 @@ type lshostsInfo struct {
 	Server    string `csv:"server"`
 	RESOURCES string `csv:"RESOURCES"`
 }
+
+// 以下是bmgroup命令的struct
+type bmgroupInfo struct {
+	GROUP_NAME string
+	HOSTS      []string
+}
+
+// 以下是bmgroup -cu命令的struct
+type computeUnitInfo struct {
+	NAME   string
+	TYPE   string
+	STATUS string
+	HOSTS  []string
+}
+
+// 以下是bhosts -l命令的struct
+type bhostLongInfo struct {
+	HOST_NAME       string
+	STATUS          string
+	DISPATCH_WINDOW string
+	ADMIN_COMMENT   string
+	LoadSched       map[string]string
+	LoadStop        map[string]string
+}
+
+// 以下是bqueues -l命令的struct
+type bqueuesLongInfo struct {
+	QUEUE_NAME      string
+	LIMITS          map[string]map[string][]string
+	POLICIES        []string
+	PREEMPTION      string
+	USERS           string
+	HOSTS           string
+	RUN_WINDOW      string
+	DISPATCH_WINDOW string
+}
+
+// 以下是bqueues -r和bhpart -r命令SHARE_INFO_FOR表格的struct
+type fairshareInfo struct {
+	NAME    string
+	PATH    string
+	ACCOUNT string
+	VALUES  map[string]string
+}
+
+// 以下是lshosts -l命令的struct
+type lshostsLongInfo struct {
+	HOST_NAME string
+	STATIC    map[string]string
+	RESOURCES []string
+}
+
+// 以下是lshosts -T命令的struct
+type lshostsTopologyInfo struct {
+	HOST_NAMES []string
+	SOCKETS    float64
+	CORES      float64
+	THREADS    float64
+	NUMA       map[string]string
+}
+
+// 以下是lshosts、bhosts和lsload按主机合并后的struct
+type hostCapacity struct {
+	HOST_NAME string
+	MODEL     string
+	NCPUS     float64
+	MAXMEM    float64
+	STATUS    string
+	MAX       float64
+	NJOBS     float64
+	RUN       float64
+	HAS_LOAD  bool
+	UT        float64
+	R1M       float64
+	MEM       float64
+}
+
+// 以下是队列可用主机容量的struct
+type queueCapacity struct {
+	HOSTS       []string
+	STATUS      map[string]float64
+	OK_HOSTS    float64
+	MAX         float64
+	USED        float64
+	FREE        float64
+	FREE_MEMORY float64
+}
+
+// 以下是lsb.events作业记录的struct
+type lsbJob struct {
+	QUEUE   string
+	USER    string
+	PROJECT string
+	STATUS  string
+	// 以下字段用于抢占和重新排队的统计
+	HOST         string
+	START_TIME   float64
+	PREEMPTED_AT float64
+	REQUEUED     bool
+}
+
+// 以下是lsb.acct JOB_FINISH记录的struct
+type lsbAcctJob struct {
+	JOB_ID         string
+	QUEUE          string
+	USER           string
+	PROJECT        string
+	RES_REQ        string
+	CHARGED_SAAP   string
+	NUM_PROCESSORS float64
+	SUBMIT_TIME    float64
+	START_TIME     float64
+	END_TIME       float64
+	CPU_TIME       float64
+	MAX_MEM        float64
+	STATUS         int64
+	EXIT_STATUS    int64
+}
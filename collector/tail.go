@@ -0,0 +1,150 @@
+package collector
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// fileTailer reads the lines appended to a log file since the previous read.
+// It remembers the inode and the byte offset of the file, so that the lines
+// written just before a log switch (lsb.events renamed to lsb.events.1, ...)
+// are read from the renamed file before the new file is read from the start.
+type fileTailer struct {
+	path   string
+	inode  uint64
+	offset int64
+}
+
+func fileInode(fi os.FileInfo) uint64 {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Ino)
+	}
+	return 0
+}
+
+// readLinesFrom calls handle for every complete line of f after offset and
+// returns the offset after the last complete line. A partial line at the end
+// of the file is left for the next read.
+func readLinesFrom(f *os.File, offset int64, handle func(line string)) (int64, error) {
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF {
+			return offset, nil
+		}
+		if err != nil {
+			return offset, err
+		}
+		offset += int64(len(line))
+		handle(strings.TrimRight(line, "\r\n"))
+	}
+}
+
+// readRotated reads the rest of the file that was renamed by a log switch,
+// it is found among path.* by its inode.
+func (t *fileTailer) readRotated(handle func(line string)) error {
+	matches, err := filepath.Glob(t.path + ".*")
+	if err != nil {
+		return err
+	}
+	for _, name := range matches {
+		fi, err := os.Stat(name)
+		if err != nil || fileInode(fi) != t.inode {
+			continue
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = readLinesFrom(f, t.offset, handle)
+		return err
+	}
+	return nil
+}
+
+// read calls handle for every line appended to the file since the previous
+// read.
+func (t *fileTailer) read(handle func(line string)) error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	inode := fileInode(fi)
+	switch {
+	case t.inode != 0 && inode != t.inode:
+		if err := t.readRotated(handle); err != nil {
+			return err
+		}
+		t.offset = 0
+	case fi.Size() < t.offset:
+		// The file was truncated.
+		t.offset = 0
+	}
+	t.inode = inode
+
+	t.offset, err = readLinesFrom(f, t.offset, handle)
+	return err
+}
+
+// readTail calls handle for every complete line of the last max bytes of the
+// file and continues from the end of the file on the next read. The line cut
+// by the start of the last max bytes is dropped.
+func (t *fileTailer) readTail(max int64, handle func(line string)) error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	offset, cut := fi.Size()-max, false
+	if offset > 0 {
+		// Reading from the byte before the start drops an empty line if the
+		// start is at the beginning of a line.
+		offset, cut = offset-1, true
+	} else {
+		offset = 0
+	}
+	end, err := readLinesFrom(f, offset, func(line string) {
+		if cut {
+			cut = false
+			return
+		}
+		handle(line)
+	})
+	if err != nil {
+		return err
+	}
+	t.inode = fileInode(fi)
+	t.offset = end
+	return nil
+}
+
+// skip moves to the end of the file without reading it.
+func (t *fileTailer) skip() error {
+	fi, err := os.Stat(t.path)
+	if err != nil {
+		return err
+	}
+	t.inode = fileInode(fi)
+	t.offset = fi.Size()
+	return nil
+}